@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"goblin/token"
+	"math/big"
 	"strings"
 )
 
@@ -43,9 +44,12 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// LetStatement binds Value to Name, or, when Pattern is set instead of
+// Name, destructures it into the names of an array or hash pattern.
 type LetStatement struct {
 	Token token.Token
 	Name *Identifier
+	Pattern Pattern
 	Value Expression
 }
 
@@ -54,11 +58,31 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+// IsDeclaration reports whether the binding was written as a function
+// declaration, fn name(params) { ... }, rather than with let.
+func (ls *LetStatement) IsDeclaration() bool {
+	return ls.Token.Type == token.FUNCTION
+}
+
+// IsConst reports whether the binding was introduced with const rather than
+// let.
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.Type == token.CONST
+}
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
+	if ls.IsDeclaration() {
+		return ls.Value.(*FunctionLiteral).format(" " + ls.Name.String())
+	}
+
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -138,6 +162,34 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type BigIntLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntLiteral) expressionNode() {}
+func (bl *BigIntLiteral) TokenLiteral() string {
+	return bl.Token.Literal
+}
+
+func (bl *BigIntLiteral) String() string {
+	return bl.Token.Literal
+}
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
 type PrefixExpression struct {
 	Token token.Token
 	Operator string
@@ -184,6 +236,91 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// RangeExpression is start..end or start..=end, optionally followed by
+// step s. Step is nil when it is left out.
+type RangeExpression struct {
+	Token token.Token // the .. or ..= token
+	Start Expression
+	End Expression
+	Step Expression
+}
+
+func (re *RangeExpression) expressionNode() {}
+func (re *RangeExpression) TokenLiteral() string {
+	return re.Token.Literal
+}
+
+func (re *RangeExpression) IsInclusive() bool {
+	return re.Token.Type == token.RANGE_INCLUSIVE
+}
+
+func (re *RangeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(re.Start.String())
+	out.WriteString(re.Token.Literal)
+	out.WriteString(re.End.String())
+	if re.Step != nil {
+		out.WriteString(" step ")
+		out.WriteString(re.Step.String())
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type AssignExpression struct {
+	Token token.Token // the =, +=, -=, *= or /= token
+	Name *Identifier
+	Operator string
+	Value Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Name.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type IndexAssignExpression struct {
+	Token token.Token // the =, +=, -=, *= or /= token
+	Left Expression
+	Index Expression
+	Operator string
+	Value Expression
+}
+
+func (ia *IndexAssignExpression) expressionNode() {}
+func (ia *IndexAssignExpression) TokenLiteral() string {
+	return ia.Token.Literal
+}
+
+func (ia *IndexAssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ia.Left.String())
+	out.WriteString("[")
+	out.WriteString(ia.Index.String())
+	out.WriteString("] " + ia.Operator + " ")
+	out.WriteString(ia.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -226,6 +363,65 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// SwitchExpression runs the body of the first case with a value equal to
+// Value, or Default when none matches. Cases do not fall through.
+type SwitchExpression struct {
+	Token token.Token
+	Value Expression
+	Cases []*SwitchCase
+	Default *BlockStatement
+}
+
+func (se *SwitchExpression) expressionNode() {}
+func (se *SwitchExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SwitchExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("switch")
+	out.WriteString(se.Value.String())
+	out.WriteString(" { ")
+
+	for _, c := range se.Cases {
+		out.WriteString(c.String())
+		out.WriteString(" ")
+	}
+
+	if se.Default != nil {
+		out.WriteString("default: ")
+		out.WriteString(se.Default.String())
+		out.WriteString(" ")
+	}
+
+	out.WriteString("}")
+
+	return out.String()
+}
+
+type SwitchCase struct {
+	Token token.Token // the case token
+	Values []Expression
+	Body *BlockStatement
+}
+
+func (sc *SwitchCase) String() string {
+	var out bytes.Buffer
+
+	values := []string{}
+	for _, v := range sc.Values {
+		values = append(values, v.String())
+	}
+
+	out.WriteString("case ")
+	out.WriteString(strings.Join(values, ", "))
+	out.WriteString(": ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
+
 type BlockStatement struct {
 	Token token.Token
 	Statements []Statement
@@ -246,9 +442,17 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// FunctionLiteral takes its Parameters in order, then any arguments left
+// over as an array in Rest. Defaults holds the default of each parameter,
+// nil for the ones without, and is nil itself when none has a default. Name
+// is the name the function is declared or bound with, if any; String leaves
+// it out, since the binding around the literal already shows it.
 type FunctionLiteral struct {
 	Token token.Token
+	Name string
 	Parameters []*Identifier
+	Defaults []Expression
+	Rest *Identifier
 	Body *BlockStatement
 }
 
@@ -258,14 +462,16 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 }
 
 func (fl *FunctionLiteral) String() string {
+	return fl.format("")
+}
+
+func (fl *FunctionLiteral) format(name string) string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
-	}
+	params := ParameterStrings(fl.Parameters, fl.Defaults, fl.Rest)
 
 	out.WriteString(fl.TokenLiteral())
+	out.WriteString(name)
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
@@ -274,6 +480,42 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// ParameterStrings prints each parameter with its default, followed by the
+// rest parameter.
+func ParameterStrings(params []*Identifier, defaults []Expression, rest *Identifier) []string {
+	out := []string{}
+	for i, p := range params {
+		if i < len(defaults) && defaults[i] != nil {
+			out = append(out, p.String() + " = " + defaults[i].String())
+		} else {
+			out = append(out, p.String())
+		}
+	}
+
+	if rest != nil {
+		out = append(out, "..." + rest.String())
+	}
+
+	return out
+}
+
+// NamedArgument passes Value to the parameter called Name. Named arguments
+// come after all positional ones in a call.
+type NamedArgument struct {
+	Token token.Token // the name token
+	Name *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode() {}
+func (na *NamedArgument) TokenLiteral() string {
+	return na.Token.Literal
+}
+
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
 type CallExpression struct {
 	Token token.Token
 	Function Expression
@@ -301,6 +543,19 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// ArgumentNames lists the names of the named arguments, which are the last
+// ones of the call. It is nil when every argument is positional.
+func (ce *CallExpression) ArgumentNames() []string {
+	var names []string
+	for _, a := range ce.Arguments {
+		if named, ok := a.(*NamedArgument); ok {
+			names = append(names, named.Name.Value)
+		}
+	}
+
+	return names
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -315,6 +570,32 @@ func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString("${")
+			out.WriteString(part.String())
+			out.WriteString("}")
+		}
+	}
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token token.Token
 	Elements []Expression
@@ -363,9 +644,49 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression is a[start:end] or a[start:end:step]. Any of the bounds
+// can be left out, in which case it is nil.
+type SliceExpression struct {
+	Token token.Token // the [ token
+	Left Expression
+	Start Expression
+	End Expression
+	Step Expression
+}
+
+func (se *SliceExpression) expressionNode() {}
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// HashLiteral merges the hashes in Spreads, in order, before setting
+// Pairs, so the keys written out override the spread ones.
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	Spreads []*SpreadExpression
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -377,6 +698,9 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
+	for _, spread := range hl.Spreads {
+		pairs = append(pairs, spread.String())
+	}
 	for key, value := range hl.Pairs {
 		pairs = append(pairs, key.String() + ":" + value.String())
 	}
@@ -388,6 +712,22 @@ func (hl *HashLiteral) String() string {
 	return out.String()
 }
 
+// SpreadExpression is ...value in an array literal, a hash literal or the
+// arguments of a call, where it stands for the elements of value.
+type SpreadExpression struct {
+	Token token.Token // the ... token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+func (se *SpreadExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SpreadExpression) String() string {
+	return "..." + se.Value.String()
+}
+
 type MacroLiteral struct {
 	Token token.Token
 	Parameters []*Identifier
@@ -437,4 +777,246 @@ func(we *WhileExpression) String() string {
 	out.WriteString(" }")
 
 	return out.String()
-}
\ No newline at end of file
+}
+
+type ForExpression struct {
+	Token token.Token
+	// Names holds the one or two loop variables before the in keyword.
+	Names []*Identifier
+	Iterable Expression
+	Body *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode() {}
+func (fe *ForExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	names := []string{}
+	for _, n := range fe.Names {
+		names = append(names, n.String())
+	}
+
+	out.WriteString("for(")
+	out.WriteString(strings.Join(names, ", "))
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(") { ")
+	out.WriteString(fe.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) String() string {
+	return bs.Token.Literal + ";"
+}
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) String() string {
+	return cs.Token.Literal + ";"
+}
+
+// MatchExpression picks the first arm whose pattern fits Value and whose
+// guard, if it has one, holds. The names a pattern binds are visible to its
+// guard and body.
+type MatchExpression struct {
+	Token token.Token
+	Value Expression
+	Arms []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode() {}
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, a := range me.Arms {
+		arms = append(arms, a.String())
+	}
+
+	out.WriteString("match")
+	out.WriteString(me.Value.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard Expression
+	Body *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+// IsCatchAll reports whether the arm matches every value: an unguarded
+// wildcard or binding.
+func (ma *MatchArm) IsCatchAll() bool {
+	if ma.Guard != nil {
+		return false
+	}
+
+	switch ma.Pattern.(type) {
+	case *WildcardPattern, *BindingPattern:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pattern is the left side of a match arm or of a destructuring let.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+type WildcardPattern struct {
+	Token token.Token // the _ token
+}
+
+func (wp *WildcardPattern) patternNode() {}
+func (wp *WildcardPattern) TokenLiteral() string {
+	return wp.Token.Literal
+}
+
+func (wp *WildcardPattern) String() string {
+	return "_"
+}
+
+// BindingPattern matches anything and binds it to Name.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (bp *BindingPattern) patternNode() {}
+func (bp *BindingPattern) TokenLiteral() string {
+	return bp.Name.TokenLiteral()
+}
+
+func (bp *BindingPattern) String() string {
+	return bp.Name.String()
+}
+
+// LiteralPattern matches values equal to a number, string or boolean
+// literal.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode() {}
+func (lp *LiteralPattern) TokenLiteral() string {
+	return lp.Value.TokenLiteral()
+}
+
+func (lp *LiteralPattern) String() string {
+	return lp.Value.String()
+}
+
+// ArrayPattern matches arrays element by element. Without a rest the array
+// must have exactly as many elements, with one it may have more, and Rest
+// binds the ones left over. Rest is nil for ..._ and a bare ... as well.
+type ArrayPattern struct {
+	Token token.Token // the [ token
+	Elements []Pattern
+	HasRest bool
+	Rest *Identifier
+}
+
+func (ap *ArrayPattern) patternNode() {}
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+
+	if ap.HasRest {
+		if ap.Rest != nil {
+			elements = append(elements, "..." + ap.Rest.String())
+		} else {
+			elements = append(elements, "..._")
+		}
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// HashPattern matches hashes that have all of its keys, whatever else they
+// hold, when the value under each key matches its pattern.
+type HashPattern struct {
+	Token token.Token // the { token
+	Pairs []*HashPatternPair
+}
+
+type HashPatternPair struct {
+	Key Expression
+	Value Pattern
+}
+
+func (hp *HashPattern) patternNode() {}
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String() + ": " + pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
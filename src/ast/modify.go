@@ -2,55 +2,149 @@ package ast
 
 type ModifierFunc func(Node) Node
 
+// Modify replaces every node of the tree with what modifier returns for it,
+// after its children have been modified, so nested unquote calls are
+// replaced innermost first.
 // TODO: Add error handling
 func Modify(node Node, modifier ModifierFunc) Node {
+	modifyChildren(node, func(child Node) Node {
+		return Modify(child, modifier)
+	})
+
+	return modifier(node)
+}
+
+// ModifyOutsideIn is Modify for modifiers that must see a node before its
+// children, like macro expansion, which hands a macro the arguments of its
+// call as they were written. The children of the node modifier returns are
+// modified afterwards, so a macro call in the expansion is expanded as well.
+func ModifyOutsideIn(node Node, modifier ModifierFunc) Node {
+	node = modifier(node)
+
+	modifyChildren(node, func(child Node) Node {
+		return ModifyOutsideIn(child, modifier)
+	})
+
+	return node
+}
+
+// modifyChildren replaces each child of node with what modify returns for
+// it.
+func modifyChildren(node Node, modify func(Node) Node) {
 	switch node := node.(type) {
 	case *Program:
 		for i := range node.Statements {
-			node.Statements[i], _ = Modify(node.Statements[i], modifier).(Statement)
+			node.Statements[i], _ = modify(node.Statements[i]).(Statement)
 		}
 	case *ExpressionStatement:
-		node.Expression, _ = Modify(node.Expression, modifier).(Expression)
+		node.Expression, _ = modify(node.Expression).(Expression)
 	case *InfixExpression:
-		node.Left, _ = Modify(node.Left, modifier).(Expression)
-		node.Right, _ = Modify(node.Right, modifier).(Expression)
+		node.Left, _ = modify(node.Left).(Expression)
+		node.Right, _ = modify(node.Right).(Expression)
+	case *RangeExpression:
+		node.Start, _ = modify(node.Start).(Expression)
+		node.End, _ = modify(node.End).(Expression)
+		if node.Step != nil {
+			node.Step, _ = modify(node.Step).(Expression)
+		}
+	case *AssignExpression:
+		node.Value, _ = modify(node.Value).(Expression)
+	case *IndexAssignExpression:
+		node.Left, _ = modify(node.Left).(Expression)
+		node.Index, _ = modify(node.Index).(Expression)
+		node.Value, _ = modify(node.Value).(Expression)
 	case *PrefixExpression:
-		node.Right, _ = Modify(node.Right, modifier).(Expression)
+		node.Right, _ = modify(node.Right).(Expression)
+	case *CallExpression:
+		node.Function, _ = modify(node.Function).(Expression)
+		for i := range node.Arguments {
+			node.Arguments[i], _ = modify(node.Arguments[i]).(Expression)
+		}
+	case *NamedArgument:
+		node.Value, _ = modify(node.Value).(Expression)
 	case *IndexExpression:
-		node.Left, _ = Modify(node.Left, modifier).(Expression)
-		node.Index, _ = Modify(node.Index, modifier).(Expression)
+		node.Left, _ = modify(node.Left).(Expression)
+		node.Index, _ = modify(node.Index).(Expression)
+	case *SliceExpression:
+		node.Left, _ = modify(node.Left).(Expression)
+		if node.Start != nil {
+			node.Start, _ = modify(node.Start).(Expression)
+		}
+		if node.End != nil {
+			node.End, _ = modify(node.End).(Expression)
+		}
+		if node.Step != nil {
+			node.Step, _ = modify(node.Step).(Expression)
+		}
 	case *IfExpression:
-		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
-		node.Consequense, _ = Modify(node.Consequense, modifier).(*BlockStatement)
+		node.Condition, _ = modify(node.Condition).(Expression)
+		node.Consequense, _ = modify(node.Consequense).(*BlockStatement)
 		if node.Alternative != nil {
-			node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
+			node.Alternative, _ = modify(node.Alternative).(*BlockStatement)
+		}
+	case *SwitchExpression:
+		node.Value, _ = modify(node.Value).(Expression)
+		for _, c := range node.Cases {
+			for i := range c.Values {
+				c.Values[i], _ = modify(c.Values[i]).(Expression)
+			}
+			c.Body, _ = modify(c.Body).(*BlockStatement)
+		}
+		if node.Default != nil {
+			node.Default, _ = modify(node.Default).(*BlockStatement)
+		}
+	case *MatchExpression:
+		node.Value, _ = modify(node.Value).(Expression)
+		for _, arm := range node.Arms {
+			if arm.Guard != nil {
+				arm.Guard, _ = modify(arm.Guard).(Expression)
+			}
+			arm.Body, _ = modify(arm.Body).(*BlockStatement)
 		}
 	case *BlockStatement:
 		for i := range node.Statements {
-			node.Statements[i], _ = Modify(node.Statements[i], modifier).(Statement)
+			node.Statements[i], _ = modify(node.Statements[i]).(Statement)
 		}
 	case *ReturnStatement:
-		node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
+		node.ReturnValue, _ = modify(node.ReturnValue).(Expression)
 	case *LetStatement:
-		node.Value, _ = Modify(node.Value, modifier).(Expression)
+		node.Value, _ = modify(node.Value).(Expression)
 	case *FunctionLiteral:
 		for i := range node.Parameters {
-			node.Parameters[i], _ = Modify(node.Parameters[i], modifier).(*Identifier) 
+			node.Parameters[i], _ = modify(node.Parameters[i]).(*Identifier) 
+		}
+		for i := range node.Defaults {
+			if node.Defaults[i] != nil {
+				node.Defaults[i], _ = modify(node.Defaults[i]).(Expression)
+			}
+		}
+		node.Body, _ = modify(node.Body).(*BlockStatement)
+	case *WhileExpression:
+		node.Condition, _ = modify(node.Condition).(Expression)
+		node.Loop, _ = modify(node.Loop).(*BlockStatement)
+	case *ForExpression:
+		node.Iterable, _ = modify(node.Iterable).(Expression)
+		node.Body, _ = modify(node.Body).(*BlockStatement)
+	case *InterpolatedString:
+		for i := range node.Parts {
+			node.Parts[i], _ = modify(node.Parts[i]).(Expression)
 		}
-		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
 	case *ArrayLiteral:
 		for i := range node.Elements {
-			node.Elements[i], _ = Modify(node.Elements[i], modifier).(Expression)
+			node.Elements[i], _ = modify(node.Elements[i]).(Expression)
 		}
 	case *HashLiteral:
 		newPairs := map[Expression]Expression{}
 		for key, value := range node.Pairs {
-			newKey, _ := Modify(key, modifier).(Expression)
-			newVal, _ := Modify(value, modifier).(Expression)
+			newKey, _ := modify(key).(Expression)
+			newVal, _ := modify(value).(Expression)
 			newPairs[newKey] = newVal
 		}
 		node.Pairs = newPairs
+		for i := range node.Spreads {
+			node.Spreads[i], _ = modify(node.Spreads[i]).(*SpreadExpression)
+		}
+	case *SpreadExpression:
+		node.Value, _ = modify(node.Value).(Expression)
 	}
-
-	return modifier(node)
 }
\ No newline at end of file
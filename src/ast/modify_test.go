@@ -84,6 +84,24 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			&WhileExpression{
+				Condition: one(),
+				Loop: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&WhileExpression{
+				Condition: two(),
+				Loop: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
 		{
 			&ReturnStatement{ReturnValue: one()},
 			&ReturnStatement{ReturnValue: two()},
@@ -110,6 +128,16 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			&CallExpression{
+				Function: one(),
+				Arguments: []Expression{one(), &NamedArgument{Value: one()}},
+			},
+			&CallExpression{
+				Function: two(),
+				Arguments: []Expression{two(), &NamedArgument{Value: two()}},
+			},
+		},
 		{
 			&ArrayLiteral{
 				Elements: []Expression{one(), two(),},
@@ -147,4 +175,45 @@ func TestModify(t *testing.T) {
 			t.Errorf("val is not %d. got=%d", 2, val.Value)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestModifyOrder(t *testing.T) {
+	input := func() Node {
+		return &PrefixExpression{Operator: "-", Right: &PrefixExpression{Operator: "!", Right: &IntegerLiteral{Value: 1}}}
+	}
+
+	visited := []string{}
+	record := func(node Node) Node {
+		if prefix, ok := node.(*PrefixExpression); ok {
+			visited = append(visited, prefix.Operator)
+		}
+		return node
+	}
+
+	Modify(input(), record)
+	if !reflect.DeepEqual(visited, []string{"!", "-"}) {
+		t.Errorf("Modify should visit the inner node first. got=%v", visited)
+	}
+
+	visited = []string{}
+	ModifyOutsideIn(input(), record)
+	if !reflect.DeepEqual(visited, []string{"-", "!"}) {
+		t.Errorf("ModifyOutsideIn should visit the outer node first. got=%v", visited)
+	}
+
+	// The children of the replacement are modified, not those of the node
+	// that was replaced.
+	replaced := ModifyOutsideIn(input(), func(node Node) Node {
+		if prefix, ok := node.(*PrefixExpression); ok && prefix.Operator == "-" {
+			return &ExpressionStatement{Expression: &IntegerLiteral{Value: 1}}
+		}
+		if integer, ok := node.(*IntegerLiteral); ok {
+			return &IntegerLiteral{Value: integer.Value + 1}
+		}
+		return node
+	})
+
+	expected := &ExpressionStatement{Expression: &IntegerLiteral{Value: 2}}
+	if !reflect.DeepEqual(replaced, expected) {
+		t.Errorf("not equal. got=%#v, expected=%#v", replaced, expected)
+	}
+}
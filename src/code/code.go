@@ -25,6 +25,57 @@ const (
 	OpGreaterThanEqual
 	OpMinus
 	OpBang
+	OpInterpolate
+	OpMod
+	OpBitAnd
+	OpBitOr
+	OpBitXor
+	OpShiftLeft
+	OpShiftRight
+	OpBitNot
+	OpJumpNotTruthyOrPop
+	OpJumpTruthyOrPop
+	OpJump
+	OpJumpNotTruthy
+	OpNull
+	OpGetGlobal
+	OpSetGlobal
+	OpArray
+	OpHash
+	OpIndex
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpGetLocal
+	OpSetLocal
+	OpGetBuiltin
+	OpClosure
+	OpGetFree
+	OpSetFree
+	OpCaptureLocal
+	OpCaptureFree
+	OpSetIndex
+	OpIterInit
+	OpIterNext
+	OpRange
+	OpIn
+	OpSlice
+	OpDup
+	OpMatchArray
+	OpMatchHash
+	OpDestructureArray
+	OpDestructureHash
+	OpJumpIfBound
+	OpCallNamed
+	OpSpread
+	OpCallSpread
+	OpLoopEnter
+	OpLoopExit
+	OpUnwind
+	OpDupPair
+	OpCaptureGlobal
+	OpClearGlobals
+	OpClearLocals
 )
 
 type Definition struct {
@@ -48,6 +99,57 @@ var definitions = map[Opcode]*Definition {
 	OpGreaterThanEqual: {"OpGreaterThanEqual", []int{}},
 	OpMinus: {"OpMinus", []int{}},
 	OpBang: {"OpBang", []int{}},
+	OpInterpolate: {"OpInterpolate", []int{2}},
+	OpMod: {"OpMod", []int{}},
+	OpBitAnd: {"OpBitAnd", []int{}},
+	OpBitOr: {"OpBitOr", []int{}},
+	OpBitXor: {"OpBitXor", []int{}},
+	OpShiftLeft: {"OpShiftLeft", []int{}},
+	OpShiftRight: {"OpShiftRight", []int{}},
+	OpBitNot: {"OpBitNot", []int{}},
+	OpJumpNotTruthyOrPop: {"OpJumpNotTruthyOrPop", []int{2}},
+	OpJumpTruthyOrPop: {"OpJumpTruthyOrPop", []int{2}},
+	OpJump: {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpNull: {"OpNull", []int{}},
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpArray: {"OpArray", []int{2}},
+	OpHash: {"OpHash", []int{2}},
+	OpIndex: {"OpIndex", []int{}},
+	OpCall: {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn: {"OpReturn", []int{}},
+	OpGetLocal: {"OpGetLocal", []int{1}},
+	OpSetLocal: {"OpSetLocal", []int{1}},
+	OpGetBuiltin: {"OpGetBuiltin", []int{1}},
+	OpClosure: {"OpClosure", []int{2, 1}},
+	OpGetFree: {"OpGetFree", []int{1}},
+	OpSetFree: {"OpSetFree", []int{1}},
+	OpCaptureLocal: {"OpCaptureLocal", []int{1}},
+	OpCaptureFree: {"OpCaptureFree", []int{1}},
+	OpSetIndex: {"OpSetIndex", []int{}},
+	OpIterInit: {"OpIterInit", []int{}},
+	OpIterNext: {"OpIterNext", []int{2, 1}},
+	OpRange: {"OpRange", []int{1}},
+	OpIn: {"OpIn", []int{}},
+	OpSlice: {"OpSlice", []int{}},
+	OpDup: {"OpDup", []int{}},
+	OpMatchArray: {"OpMatchArray", []int{2, 1}},
+	OpMatchHash: {"OpMatchHash", []int{2}},
+	OpDestructureArray: {"OpDestructureArray", []int{}},
+	OpDestructureHash: {"OpDestructureHash", []int{}},
+	OpJumpIfBound: {"OpJumpIfBound", []int{2, 1}},
+	OpCallNamed: {"OpCallNamed", []int{1, 2}},
+	OpSpread: {"OpSpread", []int{}},
+	OpCallSpread: {"OpCallSpread", []int{2}},
+	OpLoopEnter: {"OpLoopEnter", []int{}},
+	OpLoopExit: {"OpLoopExit", []int{}},
+	OpUnwind: {"OpUnwind", []int{}},
+	OpDupPair: {"OpDupPair", []int{}},
+	OpCaptureGlobal: {"OpCaptureGlobal", []int{2}},
+	OpClearGlobals: {"OpClearGlobals", []int{2, 2}},
+	OpClearLocals: {"OpClearLocals", []int{1, 1}},
 }
 
 func Lookup(op byte)(*Definition, error) {
@@ -79,6 +181,8 @@ func Make(op Opcode, operands ...int) []byte {
 		switch width {
 		case 2:
 			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
 		}
 		offset += width
 	}
@@ -117,6 +221,8 @@ func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
 		return def.Name
 	case 1:
 		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
 	}
 
 	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
@@ -130,6 +236,8 @@ func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
 		switch width {
 		case 2:
 			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
 		}
 		offset += width
 	}
@@ -139,4 +247,8 @@ func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
 
 func ReadUint16(ins Instructions) uint16 {
 	return binary.BigEndian.Uint16(ins)
-}
\ No newline at end of file
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
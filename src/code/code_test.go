@@ -11,6 +11,9 @@ func TestMake(t *testing.T) {
 		{OpConstant, []int{65534}, []byte{byte(OpConstant), 0xFF, 0xFE}},
 		{OpConstant, []int{8}, []byte{byte(OpConstant), 0x00, 0x08}},
 		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetLocal, []int{255}, []byte{byte(OpGetLocal), 255}},
+		{OpClosure, []int{65534, 255}, []byte{byte(OpClosure), 0xFF, 0xFE, 0xFF}},
+		{OpIterNext, []int{65534, 2}, []byte{byte(OpIterNext), 0xFF, 0xFE, 0x02}},
 	}
 
 	for _, tt := range tests {
@@ -47,6 +50,8 @@ func TestInstructionsString(t *testing.T) {
 		Make(OpMinus),
 		Make(OpBang),
 		Make(OpPop),
+		Make(OpGetLocal, 1),
+		Make(OpClosure, 65535, 255),
 	}
 
 	expected := `0000 OpConstant 1
@@ -66,6 +71,8 @@ func TestInstructionsString(t *testing.T) {
 0020 OpMinus
 0021 OpBang
 0022 OpPop
+0023 OpGetLocal 1
+0025 OpClosure 65535 255
 `
 
 	concatted := Instructions{}
@@ -87,6 +94,12 @@ func TestReadOperands(t *testing.T) {
 		{
 			OpConstant, []int{65535}, 2,
 		},
+		{
+			OpGetLocal, []int{255}, 1,
+		},
+		{
+			OpClosure, []int{65535, 255}, 3,
+		},
 	}
 
 	for _, tt := range tests {
@@ -4,6 +4,7 @@ const (
 	RESET = "\033[0m"
 	RED = "\033[31m"
 	GREEN = "\033[32;1m"
+	YELLOW = "\033[33m"
 )
 
 
@@ -5,23 +5,80 @@ import (
 	"goblin/ast"
 	"goblin/code"
 	"goblin/object"
+	"goblin/operator"
+	"goblin/token"
+	"sort"
 )
 
 type Compiler struct {
-	instructions code.Instructions
   constants []object.Object	
+	symbolTable *SymbolTable
+	scopes []CompilationScope
+	scopeIndex int
+}
+
+type EmittedInstruction struct {
+	Opcode code.Opcode
+	Position int
+}
+
+// CompilationScope collects the instructions of one function body, or of the
+// main program, together with the source map for them.
+type CompilationScope struct {
+	instructions code.Instructions
+	positions map[int]token.Token
+	lastInstruction EmittedInstruction
+	previousInstruction EmittedInstruction
+
+	// loops holds the loops being compiled in this scope, innermost last.
+	loops []*loop
+}
+
+// loop records where break and continue inside a loop body jump to. The
+// targets of break are only known once the whole loop has been compiled, so
+// their jumps are patched when the loop is left.
+type loop struct {
+	start int
+	breaks []int
+
+	// iterating loops keep their iterator on the stack, which break has to
+	// remove. while loops instead leave a value there, which break and
+	// continue have to provide.
+	iterating bool
 }
 
 func New() *Compiler {
-	return &Compiler{
+	mainScope := CompilationScope{
 		instructions: code.Instructions{},
+		positions: map[int]token.Token{},
+	}
+
+	symbolTable := NewSymbolTable()
+	for i, v := range object.Builtins {
+		symbolTable.DefineBuiltin(i, v.Name)
+	}
+
+	return &Compiler{
 		constants: []object.Object{},
+		symbolTable: symbolTable,
+		scopes: []CompilationScope{mainScope},
+		scopeIndex: 0,
 	}
 }
 
+// NewWithState keeps the globals and constants of earlier compilations, which
+// lets the repl build on what was defined on previous lines.
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	compiler := New()
+	compiler.symbolTable = s
+	compiler.constants = constants
+	return compiler
+}
+
 func(c *Compiler) Compile(node ast.Node) error {
 	switch node := node.(type) {
 	case *ast.Program:
+		c.declareFunctions(node.Statements)
 		for _, s := range node.Statements {
 			err := c.Compile(s)
 			if err != nil {
@@ -34,58 +91,587 @@ func(c *Compiler) Compile(node ast.Node) error {
 			return err
 		}
 		c.emit(code.OpPop)
-	case *ast.InfixExpression:
+	case *ast.BlockStatement:
+		c.declareFunctions(node.Statements)
+		for _, s := range node.Statements {
+			err := c.Compile(s)
+			if err != nil {
+				return err
+			}
+		}
+	case *ast.LetStatement:
+		if node.Pattern != nil {
+			err := c.Compile(node.Value)
+			if err != nil {
+				return err
+			}
+			return c.compileDestructure(node.Pattern, node.IsConst())
+		}
+
+		if c.symbolTable.IsConstant(node.Name.Value) {
+			return positionedError(node.Name.Token, "cannot redeclare constant %s", node.Name.Value)
+		}
+
+		// A function may refer to the name it is being bound to, so that name
+		// has to resolve while its body is compiled.
+		_, isFunction := node.Value.(*ast.FunctionLiteral)
+
+		var symbol Symbol
+		if isFunction {
+			symbol = c.defineBinding(node.Name.Value, node.IsConst())
+		}
+
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if !isFunction {
+			symbol = c.defineBinding(node.Name.Value, node.IsConst())
+		}
+		c.storeSymbol(symbol)
+	case *ast.ReturnStatement:
+		err := c.Compile(node.ReturnValue)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			return positionedError(node.Token, "identifier not found: %s", node.Value)
+		}
+
+		// The vm names the identifier when it reads a function declared
+		// further down before its declaration has run.
+		pos := len(c.currentInstructions())
+		c.loadSymbol(symbol)
+		c.scopes[c.scopeIndex].positions[pos] = node.Token
+	case *ast.AssignExpression:
+		symbol, ok := c.symbolTable.Resolve(node.Name.Value)
+		if !ok || symbol.Scope == BuiltinScope {
+			return positionedError(node.Token, "cannot assign to undefined variable %s", node.Name.Value)
+		}
+
+		if symbol.Constant {
+			return positionedError(node.Token, "cannot assign to constant %s", node.Name.Value)
+		}
+
+		if node.Operator != "=" {
+			c.loadSymbol(symbol)
+		}
+
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if binary, ok := operator.Compound[node.Token.Type]; ok {
+			infix, _ := operator.Lookup(binary)
+			c.emitWithPosition(node.Token, infix.Opcode)
+		}
+
+		c.storeSymbol(symbol)
+		c.loadSymbol(symbol)
+	case *ast.IndexAssignExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
 
-		if node.Operator == "<" {
-			err := c.reverseInfixCompile(code.OpGreaterThan, *node)
+		err = c.Compile(node.Index)
+		if err != nil {
+			return err
+		}
+
+		// A compound assignment reads the element through copies of the
+		// left and index already on the stack, so neither is compiled twice.
+		if node.Operator != "=" {
+			c.emit(code.OpDupPair)
+			c.emitWithPosition(node.Token, code.OpIndex)
+		}
+
+		err = c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if binary, ok := operator.Compound[node.Token.Type]; ok {
+			infix, _ := operator.Lookup(binary)
+			c.emitWithPosition(node.Token, infix.Opcode)
+		}
+
+		c.emitWithPosition(node.Token, code.OpSetIndex)
+	case *ast.IfExpression:
+		err := c.Compile(node.Condition)
+		if err != nil {
+			return err
+		}
+
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		err = c.compileBlockValue(node.Consequense)
+		if err != nil {
+			return err
+		}
+
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+		if node.Alternative == nil {
+			c.emit(code.OpNull)
+		} else {
+			err := c.compileBlockValue(node.Alternative)
 			if err != nil {
 				return err
 			}
-			return nil
 		}
-		if node.Operator == "<=" {
-			err := c.reverseInfixCompile(code.OpGreaterThanEqual, *node)
+
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	case *ast.SwitchExpression:
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+
+		// The value stays on the stack while it is compared against the
+		// cases, and the body that runs pops it first.
+		endJumps := []int{}
+		for _, sc := range node.Cases {
+			bodyJumps := []int{}
+			nextCase := 0
+
+			for i, v := range sc.Values {
+				c.emit(code.OpDup)
+				err := c.Compile(v)
+				if err != nil {
+					return err
+				}
+				c.emitWithPosition(sc.Token, code.OpEqual)
+
+				if i == len(sc.Values) - 1 {
+					nextCase = c.emit(code.OpJumpNotTruthy, 9999)
+					break
+				}
+
+				nextValue := c.emit(code.OpJumpNotTruthy, 9999)
+				bodyJumps = append(bodyJumps, c.emit(code.OpJump, 9999))
+				c.changeOperand(nextValue, len(c.currentInstructions()))
+			}
+
+			for _, pos := range bodyJumps {
+				c.changeOperand(pos, len(c.currentInstructions()))
+			}
+
+			c.emit(code.OpPop)
+			err := c.compileBlockValue(sc.Body)
 			if err != nil {
 				return err
 			}
-			return nil
+			endJumps = append(endJumps, c.emit(code.OpJump, 9999))
+
+			c.changeOperand(nextCase, len(c.currentInstructions()))
+		}
+
+		c.emit(code.OpPop)
+		if node.Default == nil {
+			c.emit(code.OpNull)
+		} else {
+			err := c.compileBlockValue(node.Default)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, pos := range endJumps {
+			c.changeOperand(pos, len(c.currentInstructions()))
+		}
+	case *ast.MatchExpression:
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
 		}
 
+		// Like a switch, the value stays on the stack until an arm is
+		// chosen. Patterns push the parts of it they look into, so a
+		// failed test first pops as many values as it sits on.
+		endJumps := []int{}
+		for _, arm := range node.Arms {
+			fails := map[int][]int{}
+
+			// Each arm binds its names in a block of its own, so that
+			// neither a failed arm nor the chosen one changes the
+			// variables around the match.
+			c.symbolTable.enterBlock()
+
+			err := c.compilePattern(arm.Pattern, 0, fails)
+			if err != nil {
+				return err
+			}
+
+			if arm.Guard != nil {
+				err := c.Compile(arm.Guard)
+				if err != nil {
+					return err
+				}
+				fails[0] = append(fails[0], c.emit(code.OpJumpNotTruthy, 9999))
+			}
+
+			c.emit(code.OpPop)
+			err = c.compileBlockValue(arm.Body)
+			if err != nil {
+				return err
+			}
+			c.symbolTable.leaveBlock()
+			endJumps = append(endJumps, c.emit(code.OpJump, 9999))
+
+			maxDepth := 0
+			for depth := range fails {
+				if depth > maxDepth {
+					maxDepth = depth
+				}
+			}
+
+			for depth := maxDepth; depth >= 0; depth-- {
+				for _, pos := range fails[depth] {
+					c.changeOperand(pos, len(c.currentInstructions()))
+				}
+				if depth > 0 {
+					c.emit(code.OpPop)
+				}
+			}
+		}
+
+		c.emit(code.OpPop)
+		c.emit(code.OpNull)
+
+		for _, pos := range endJumps {
+			c.changeOperand(pos, len(c.currentInstructions()))
+		}
+	case *ast.WhileExpression:
+		c.emit(code.OpLoopEnter)
+
+		// The loop leaves the value of its last iteration on the stack, or
+		// null when the body never ran.
+		c.emit(code.OpNull)
+
+		loopStart := len(c.currentInstructions())
+		c.enterLoop(loopStart, false)
+
+		err := c.Compile(node.Condition)
+		if err != nil {
+			return err
+		}
+
+		exitPos := c.emit(code.OpJumpNotTruthy, 9999)
+		c.emit(code.OpPop)
+
+		err = c.compileBlockValue(node.Loop)
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpJump, loopStart)
+		c.changeOperand(exitPos, len(c.currentInstructions()))
+		c.leaveLoop()
+	case *ast.ForExpression:
+		err := c.Compile(node.Iterable)
+		if err != nil {
+			return err
+		}
+
+		c.emitWithPosition(node.Token, code.OpIterInit)
+		c.emit(code.OpLoopEnter)
+
+		loopStart := len(c.currentInstructions())
+		c.enterLoop(loopStart, true)
+		exitPos := c.emit(code.OpIterNext, 9999, len(node.Names))
+
+		// Every iteration binds the names afresh in a block of its own, so
+		// they neither overwrite the variables outside the loop nor are
+		// shared by the closures of different iterations.
+		c.symbolTable.enterBlock()
+		sizeBlock := c.clearBlock()
+
+		symbols := make([]Symbol, len(node.Names))
+		for i, name := range node.Names {
+			symbols[i] = c.symbolTable.Define(name.Value)
+		}
+
+		// The values are pushed in the order of the names, so the last
+		// name is stored first.
+		for i := len(symbols) - 1; i >= 0; i-- {
+			c.storeSymbol(symbols[i])
+		}
+
+		err = c.Compile(node.Body)
+		if err != nil {
+			return err
+		}
+
+		sizeBlock()
+		c.symbolTable.leaveBlock()
+
+		c.emit(code.OpJump, loopStart)
+		c.changeOperand(exitPos, len(c.currentInstructions()))
+		c.leaveLoop()
+
+		// OpIterNext drops the iterator once it is exhausted, break does the
+		// same before jumping here, and the loop itself evaluates to null.
+		c.emit(code.OpNull)
+	case *ast.BreakStatement:
+		// break and continue may sit inside an expression, so the
+		// temporaries it pushed are dropped first.
+		c.emit(code.OpUnwind)
+
+		l := c.currentLoop()
+		if l.iterating {
+			c.emit(code.OpPop)
+		} else {
+			c.emit(code.OpNull)
+		}
+
+		jumpPos := c.emit(code.OpJump, 9999)
+		l.breaks = append(l.breaks, jumpPos)
+	case *ast.ContinueStatement:
+		c.emit(code.OpUnwind)
+
+		l := c.currentLoop()
+		if !l.iterating {
+			c.emit(code.OpNull)
+		}
+
+		c.emit(code.OpJump, l.start)
+	case *ast.FunctionLiteral:
+		c.enterScope()
+
+		for _, p := range node.Parameters {
+			c.symbolTable.Define(p.Value)
+		}
+		if node.Rest != nil {
+			c.symbolTable.Define(node.Rest.Value)
+		}
+
+		err := c.compileDefaults(node)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.Body)
+		if err != nil {
+			return err
+		}
+
+		if c.lastInstructionIs(code.OpPop) {
+			c.replaceLastPopWithReturn()
+		}
+		if !c.lastInstructionIs(code.OpReturnValue) {
+			c.emit(code.OpReturn)
+		}
+
+		freeSymbols := c.symbolTable.FreeSymbols
+		numLocals := c.symbolTable.numDefinitions
+		instructions, positions := c.leaveScope()
+
+		for _, s := range freeSymbols {
+			c.captureSymbol(s)
+		}
+
+		compiledFn := &object.CompiledFunction{
+			Name: node.Name,
+			Parameters: ast.ParameterStrings(node.Parameters, node.Defaults, node.Rest),
+			Instructions: instructions,
+			Positions: positions,
+			NumLocals: numLocals,
+			Arity: object.NewArity(node.Parameters, node.Defaults, node.Rest),
+		}
+
+		fnIndex := c.addConstant(compiledFn)
+		c.emit(code.OpClosure, fnIndex, len(freeSymbols))
+	case *ast.CallExpression:
+		err := c.Compile(node.Function)
+		if err != nil {
+			return err
+		}
+
+		names := node.ArgumentNames()
+		positional := node.Arguments[:len(node.Arguments) - len(names)]
+
+		// With a spread the number of arguments is only known at run time,
+		// so the positional ones are passed as an array.
+		if hasSpread(positional) {
+			err := c.compileElements(positional)
+			if err != nil {
+				return err
+			}
+
+			for _, a := range node.Arguments[len(positional):] {
+				err := c.Compile(a)
+				if err != nil {
+					return err
+				}
+			}
+
+			c.emitWithPosition(node.Token, code.OpCallSpread, c.addArgumentNames(names))
+			break
+		}
+
+		for _, a := range node.Arguments {
+			err := c.Compile(a)
+			if err != nil {
+				return err
+			}
+		}
+
+		if names == nil {
+			c.emitWithPosition(node.Token, code.OpCall, len(node.Arguments))
+			break
+		}
+
+		c.emitWithPosition(node.Token, code.OpCallNamed, len(node.Arguments), c.addArgumentNames(names))
+	case *ast.NamedArgument:
+		// The call passes the names along separately, so only the value is
+		// compiled here.
+		return c.Compile(node.Value)
+	case *ast.ArrayLiteral:
+		err := c.compileElements(node.Elements)
+		if err != nil {
+			return err
+		}
+	case *ast.HashLiteral:
+		// The spread hashes go into an empty hash first, so that the pairs
+		// written out are merged in last and win.
+		if len(node.Spreads) > 0 {
+			c.emit(code.OpHash, 0)
+		}
+
+		for _, spread := range node.Spreads {
+			err := c.Compile(spread.Value)
+			if err != nil {
+				return err
+			}
+			c.emitWithPosition(spread.Token, code.OpSpread)
+		}
+
+		keys := []ast.Expression{}
+		for k := range node.Pairs {
+			keys = append(keys, k)
+		}
+
+		// Go randomizes map order; sorting keeps the bytecode deterministic.
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+
+		for _, k := range keys {
+			err := c.Compile(k)
+			if err != nil {
+				return err
+			}
+			err = c.Compile(node.Pairs[k])
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emitWithPosition(node.Token, code.OpHash, len(node.Pairs) * 2)
+		if len(node.Spreads) > 0 {
+			c.emit(code.OpSpread)
+		}
+	case *ast.IndexExpression:
 		err := c.Compile(node.Left)
 		if err != nil {
 			return err
 		}
 
-		err = c.Compile(node.Right)
+		err = c.Compile(node.Index)
 		if err != nil {
 			return err
 		}
-		 
-		switch node.Operator {
-		case "+":
-			c.emit(code.OpAdd)
-		case "-":
-			c.emit(code.OpSub)
-		case "*":
-			c.emit(code.OpMul)
-		case "/":
-			c.emit(code.OpDiv)
-		case "**":
-			c.emit(code.OpExp)
-		case "==":
-			c.emit(code.OpEqual)
-		case "!=":
-			c.emit(code.OpNotEqual)
-		case ">":
-			c.emit(code.OpGreaterThan)
-		case ">=":
-			c.emit(code.OpGreaterThanEqual)
-		default:
+
+		c.emitWithPosition(node.Token, code.OpIndex)
+	case *ast.SliceExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+
+		for _, bound := range []ast.Expression{node.Start, node.End, node.Step} {
+			err := c.compileOptional(bound)
+			if err != nil {
+				return err
+			}
+		}
+
+		c.emitWithPosition(node.Token, code.OpSlice)
+	case *ast.InfixExpression:
+
+		infix, ok := operator.Lookup(node.Token.Type)
+		if !ok {
 			return fmt.Errorf("unknown operator %s", node.Operator)
 		}
+
+		if infix.ShortCircuit {
+			return c.compileShortCircuit(infix.Opcode, node)
+		}
+
+		first, second := node.Left, node.Right
+		if infix.Swapped {
+			first, second = second, first
+		}
+
+		err := c.Compile(first)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(second)
+		if err != nil {
+			return err
+		}
+
+		c.emitWithPosition(node.Token, infix.Opcode)
+	case *ast.RangeExpression:
+		err := c.Compile(node.Start)
+		if err != nil {
+			return err
+		}
+
+		err = c.Compile(node.End)
+		if err != nil {
+			return err
+		}
+
+		err = c.compileOptional(node.Step)
+		if err != nil {
+			return err
+		}
+
+		inclusive := 0
+		if node.IsInclusive() {
+			inclusive = 1
+		}
+		c.emitWithPosition(node.Token, code.OpRange, inclusive)
 	case *ast.IntegerLiteral:
 		integer := &object.Integer{Value: node.Value}
 		c.emit(code.OpConstant, c.addConstant(integer))
+	case *ast.BigIntLiteral:
+		integer := &object.BigInt{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(integer))
+	case *ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(str))
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			err := c.Compile(part)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpInterpolate, len(node.Parts))
 	case *ast.Boolean:
 		if node.Value {
 			c.emit(code.OpTrue)
@@ -100,9 +686,11 @@ func(c *Compiler) Compile(node ast.Node) error {
 
 		switch node.Operator {
 		case "-":
-			c.emit(code.OpMinus)
+			c.emitWithPosition(node.Token, code.OpMinus)
 		case "!":
 			c.emit(code.OpBang)
+		case "~":
+			c.emitWithPosition(node.Token, code.OpBitNot)
 		default:
 			return fmt.Errorf("unkonwn operator %s", node.Operator)
 		}
@@ -119,38 +707,543 @@ func (c *Compiler) addConstant(obj object.Object) int {
 func (c *Compiler) emit(op code.Opcode, operands ...int) int {
 	ins := code.Make(op, operands...)
 	pos := c.addInstruction(ins)
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+// emitWithPosition remembers which source token produced the instruction so
+// the vm can point at it when the instruction fails at runtime.
+func (c *Compiler) emitWithPosition(tok token.Token, op code.Opcode, operands ...int) int {
+	pos := c.emit(op, operands...)
+	c.scopes[c.scopeIndex].positions[pos] = tok
 	return pos
 }
 
 func (c *Compiler) addInstruction(ins []byte) int {
-	posNewInstruction := len(c.instructions)
-	c.instructions = append(c.instructions, ins...)
+	posNewInstruction := len(c.currentInstructions())
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), ins...)
 	return posNewInstruction
 }
 
-func (c *Compiler) reverseInfixCompile(op code.Opcode, node ast.InfixExpression) error {
-	err := c.Compile(node.Right)
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	last := c.scopes[c.scopeIndex].lastInstruction
+	previous := c.scopes[c.scopeIndex].previousInstruction
+
+	c.scopes[c.scopeIndex].instructions = c.currentInstructions()[:last.Position]
+	c.scopes[c.scopeIndex].lastInstruction = previous
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) enterScope() {
+	scope := CompilationScope{
+		instructions: code.Instructions{},
+		positions: map[int]token.Token{},
+	}
+	c.scopes = append(c.scopes, scope)
+	c.scopeIndex++
+
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() (code.Instructions, map[int]token.Token) {
+	scope := c.scopes[c.scopeIndex]
+
+	c.scopes = c.scopes[:len(c.scopes) - 1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return scope.instructions, scope.positions
+}
+
+// compileBlockValue compiles a block whose last expression is the value of
+// the surrounding expression. A block that ends without one yields null.
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	start := len(c.currentInstructions())
+
+	err := c.Compile(block)
 	if err != nil {
 		return err
 	}
 
-	err = c.Compile(node.Left)
+	if len(c.currentInstructions()) > start && c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+// compilePattern tests the value on top of the stack against pattern and
+// leaves the stack as it found it when the value matches. depth counts the
+// values pushed above the matched one, and fails collects the jumps taken on
+// a mismatch by the depth they leave behind.
+func (c *Compiler) compilePattern(pattern ast.Pattern, depth int, fails map[int][]int) error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+	case *ast.BindingPattern:
+		return c.bindPatternName(pattern.Name)
+	case *ast.LiteralPattern:
+		c.emit(code.OpDup)
+		err := c.Compile(pattern.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(code.OpEqual)
+		fails[depth] = append(fails[depth], c.emit(code.OpJumpNotTruthy, 9999))
+	case *ast.ArrayPattern:
+		hasRest := 0
+		if pattern.HasRest {
+			hasRest = 1
+		}
+		c.emit(code.OpMatchArray, len(pattern.Elements), hasRest)
+		fails[depth] = append(fails[depth], c.emit(code.OpJumpNotTruthy, 9999))
+
+		for i, el := range pattern.Elements {
+			if _, ok := el.(*ast.WildcardPattern); ok {
+				continue
+			}
+
+			c.emit(code.OpDup)
+			c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: int64(i)}))
+			c.emit(code.OpIndex)
+
+			err := c.compilePattern(el, depth + 1, fails)
+			if err != nil {
+				return err
+			}
+			c.emit(code.OpPop)
+		}
+
+		if pattern.Rest != nil {
+			c.emit(code.OpDup)
+			c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: int64(len(pattern.Elements))}))
+			c.emit(code.OpNull)
+			c.emit(code.OpNull)
+			c.emit(code.OpSlice)
+
+			err := c.bindPatternName(pattern.Rest)
+			if err != nil {
+				return err
+			}
+			c.emit(code.OpPop)
+		}
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			err := c.Compile(pair.Key)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpMatchHash, len(pattern.Pairs))
+		fails[depth] = append(fails[depth], c.emit(code.OpJumpNotTruthy, 9999))
+
+		for _, pair := range pattern.Pairs {
+			if _, ok := pair.Value.(*ast.WildcardPattern); ok {
+				continue
+			}
+
+			c.emit(code.OpDup)
+			err := c.Compile(pair.Key)
+			if err != nil {
+				return err
+			}
+			c.emit(code.OpIndex)
+
+			err = c.compilePattern(pair.Value, depth + 1, fails)
+			if err != nil {
+				return err
+			}
+			c.emit(code.OpPop)
+		}
+	default:
+		return fmt.Errorf("unknown pattern: %s", pattern.String())
+	}
+
+	return nil
+}
+
+// bindPatternName stores a copy of the value on top of the stack in name.
+func (c *Compiler) bindPatternName(name *ast.Identifier) error {
+	if c.symbolTable.IsConstant(name.Value) {
+		return positionedError(name.Token, "cannot redeclare constant %s", name.Value)
+	}
+
+	c.emit(code.OpDup)
+	c.storeSymbol(c.symbolTable.Define(name.Value))
+	return nil
+}
+
+// compileElements leaves an array of elements on the stack. Without spreads
+// that is a single OpArray. Otherwise each spread value, and each run of
+// plain elements between them, is appended to the array built so far.
+func (c *Compiler) compileElements(elements []ast.Expression) error {
+	spreading := false
+	run := 0
+
+	for _, el := range elements {
+		spread, ok := el.(*ast.SpreadExpression)
+		if !ok {
+			err := c.Compile(el)
+			if err != nil {
+				return err
+			}
+			run++
+			continue
+		}
+
+		if !spreading || run > 0 {
+			c.emit(code.OpArray, run)
+			if spreading {
+				c.emit(code.OpSpread)
+			}
+		}
+		spreading = true
+		run = 0
+
+		err := c.Compile(spread.Value)
+		if err != nil {
+			return err
+		}
+		c.emitWithPosition(spread.Token, code.OpSpread)
+	}
+
+	if !spreading || run > 0 {
+		c.emit(code.OpArray, run)
+		if spreading {
+			c.emit(code.OpSpread)
+		}
+	}
+
+	return nil
+}
+
+func hasSpread(elements []ast.Expression) bool {
+	for _, el := range elements {
+		if _, ok := el.(*ast.SpreadExpression); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addArgumentNames adds the names of the named arguments of a call as a
+// constant array of strings.
+func (c *Compiler) addArgumentNames(names []string) int {
+	constant := &object.Array{Elements: []object.Object{}}
+	for _, name := range names {
+		constant.Elements = append(constant.Elements, &object.String{Value: name})
+	}
+
+	return c.addConstant(constant)
+}
+
+// compileDefaults starts a function by filling in the parameters a call
+// left to their defaults. Like in the evaluator, a default only sees the
+// parameters before it.
+func (c *Compiler) compileDefaults(node *ast.FunctionLiteral) error {
+	for i, d := range node.Defaults {
+		if d == nil {
+			continue
+		}
+
+		later := []string{}
+		for _, p := range node.Parameters[i:] {
+			later = append(later, p.Value)
+		}
+		if node.Rest != nil {
+			later = append(later, node.Rest.Value)
+		}
+
+		jumpPos := c.emit(code.OpJumpIfBound, 9999, i)
+
+		restore := c.symbolTable.hide(later)
+		err := c.Compile(d)
+		restore()
+		if err != nil {
+			return err
+		}
+
+		c.emit(code.OpSetLocal, i)
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+	}
+
+	return nil
+}
+
+// compileDestructure binds the names of a let pattern to the parts of the
+// value on top of the stack, which it pops. Elements past the end of an
+// array and missing keys index to null.
+func (c *Compiler) compileDestructure(pattern ast.Pattern, constant bool) error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		c.emit(code.OpPop)
+	case *ast.BindingPattern:
+		return c.storeLetName(pattern.Name, constant)
+	case *ast.ArrayPattern:
+		c.emitWithPosition(pattern.Token, code.OpDestructureArray)
+
+		for i, el := range pattern.Elements {
+			if _, ok := el.(*ast.WildcardPattern); ok {
+				continue
+			}
+
+			c.emit(code.OpDup)
+			c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: int64(i)}))
+			c.emit(code.OpIndex)
+
+			err := c.compileDestructure(el, constant)
+			if err != nil {
+				return err
+			}
+		}
+
+		if pattern.Rest != nil {
+			c.emit(code.OpDup)
+			c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: int64(len(pattern.Elements))}))
+			c.emit(code.OpNull)
+			c.emit(code.OpNull)
+			c.emit(code.OpSlice)
+
+			err := c.storeLetName(pattern.Rest, constant)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpPop)
+	case *ast.HashPattern:
+		c.emitWithPosition(pattern.Token, code.OpDestructureHash)
+
+		for _, pair := range pattern.Pairs {
+			if _, ok := pair.Value.(*ast.WildcardPattern); ok {
+				continue
+			}
+
+			c.emit(code.OpDup)
+			err := c.Compile(pair.Key)
+			if err != nil {
+				return err
+			}
+			c.emit(code.OpIndex)
+
+			err = c.compileDestructure(pair.Value, constant)
+			if err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpPop)
+	default:
+		return fmt.Errorf("unknown pattern: %s", pattern.String())
+	}
+
+	return nil
+}
+
+// storeLetName pops the value on top of the stack into name.
+func (c *Compiler) storeLetName(name *ast.Identifier, constant bool) error {
+	if c.symbolTable.IsConstant(name.Value) {
+		return positionedError(name.Token, "cannot redeclare constant %s", name.Value)
+	}
+
+	c.storeSymbol(c.defineBinding(name.Value, constant))
+	return nil
+}
+
+// compileOptional compiles an expression that may be left out of the
+// source, standing in null for a missing one.
+func (c *Compiler) compileOptional(node ast.Expression) error {
+	if node == nil {
+		c.emit(code.OpNull)
+		return nil
+	}
+
+	return c.Compile(node)
+}
+
+// declareFunctions defines the names of the function declarations among
+// statements up front, so that the functions of a block can call the ones
+// declared after them, as they can in the evaluator.
+func (c *Compiler) declareFunctions(statements []ast.Statement) {
+	for _, s := range statements {
+		if let, ok := s.(*ast.LetStatement); ok && let.IsDeclaration() {
+			c.symbolTable.Define(let.Name.Value)
+		}
+	}
+}
+
+func (c *Compiler) defineBinding(name string, constant bool) Symbol {
+	if constant {
+		return c.symbolTable.DefineConstant(name)
+	}
+
+	return c.symbolTable.Define(name)
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	}
+}
+
+func (c *Compiler) storeSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpSetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	}
+}
+
+// captureSymbol pushes the variable a new closure shares with the scope it
+// is created in, rather than a copy of its current value.
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpCaptureGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpCaptureLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpCaptureFree, s.Index)
+	}
+}
+
+// clearBlock emits the instruction that unbinds the variables a block is
+// about to define, so that closures made by an earlier run of the block
+// keep the variables of that run. The block's slots follow each other, and
+// how many there are is only known once the block has been compiled, so
+// the returned function fills that in.
+func (c *Compiler) clearBlock() func() {
+	first := c.symbolTable.numDefinitions
+
+	op := code.Opcode(code.OpClearLocals)
+	if c.symbolTable.Outer == nil {
+		op = code.OpClearGlobals
+	}
+	pos := c.emit(op, first, 0)
+
+	return func() {
+		c.replaceInstruction(pos, code.Make(op, first, c.symbolTable.numDefinitions - first))
+	}
+}
+
+func positionedError(tok token.Token, format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	return fmt.Errorf("%s at line %d, column %d", msg, tok.Line, tok.Column)
+}
+
+// compileShortCircuit leaves the left operand on the stack and skips
+// the right operand whenever the left one already decides the result.
+func (c *Compiler) compileShortCircuit(jump code.Opcode, node *ast.InfixExpression) error {
+	err := c.Compile(node.Left)
 	if err != nil {
 		return err
 	}
 
-	c.emit(op)
+	jumpPos := c.emit(jump, 9999)
+
+	err = c.Compile(node.Right)
+	if err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
 	return nil
-} 
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	for i := 0; i < len(newInstruction); i++ {
+		c.scopes[c.scopeIndex].instructions[pos + i] = newInstruction[i]
+	}
+}
+
+// changeOperand replaces the first operand of the instruction at opPos and
+// keeps any others it has.
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	ins := c.currentInstructions()
+	op := code.Opcode(ins[opPos])
+	def, _ := code.Lookup(byte(op))
+
+	operands, _ := code.ReadOperands(def, ins[opPos + 1:])
+	operands[0] = operand
+	newInstruction := code.Make(op, operands...)
+
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+func (c *Compiler) enterLoop(start int, iterating bool) {
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = append(scope.loops, &loop{start: start, iterating: iterating})
+}
+
+// leaveLoop points the breaks of the innermost loop at its exit, where the
+// loop's entry in the vm is dropped again.
+func (c *Compiler) leaveLoop() {
+	scope := &c.scopes[c.scopeIndex]
+	l := scope.loops[len(scope.loops) - 1]
+	scope.loops = scope.loops[:len(scope.loops) - 1]
+
+	for _, pos := range l.breaks {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+	c.emit(code.OpLoopExit)
+}
+
+// currentLoop returns the innermost loop. The parser only accepts break and
+// continue inside a loop body, so there always is one.
+func (c *Compiler) currentLoop() *loop {
+	loops := c.scopes[c.scopeIndex].loops
+	return loops[len(loops) - 1]
+}
 
 func (c *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
-		Instructions: c.instructions,
+		Instructions: c.currentInstructions(),
 		Constants: c.constants,
+		Positions: c.scopes[c.scopeIndex].positions,
 	}
 }
 
 type Bytecode struct {
 	Instructions code.Instructions
 	Constants []object.Object
+	Positions map[int]token.Token
 }
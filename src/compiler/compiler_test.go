@@ -7,6 +7,7 @@ import (
 	"goblin/lexer"
 	"goblin/object"
 	"goblin/parser"
+	"math/big"
 	"testing"
 )
 
@@ -78,6 +79,61 @@ func TestIntegerArithmetic(t *testing.T) {
 				code.Make(code.OpPop),
 			},
 		},
+		{
+			input: "2 ** 3 ** 2",
+			expectedConstants: []interface{}{2, 3, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpExp),
+				code.Make(code.OpExp),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "18446744073709551616 - 1",
+			expectedConstants: []interface{}{new(big.Int).Lsh(big.NewInt(1), 64), 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSub),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "5 % 2; 5 & 2; 5 | 2; 5 ^ 2; 5 << 2; 5 >> 2; ~5",
+			expectedConstants: []interface{}{5, 2, 5, 2, 5, 2, 5, 2, 5, 2, 5, 2, 5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMod),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpBitAnd),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 4),
+				code.Make(code.OpConstant, 5),
+				code.Make(code.OpBitOr),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 6),
+				code.Make(code.OpConstant, 7),
+				code.Make(code.OpBitXor),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 8),
+				code.Make(code.OpConstant, 9),
+				code.Make(code.OpShiftLeft),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 10),
+				code.Make(code.OpConstant, 11),
+				code.Make(code.OpShiftRight),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 12),
+				code.Make(code.OpBitNot),
+				code.Make(code.OpPop),
+			},
+		},
 		{
 			input: "-5",
 			expectedConstants: []interface{}{5},
@@ -92,6 +148,32 @@ func TestIntegerArithmetic(t *testing.T) {
 	runCompilerTests(t, tests)
 } 
 
+func TestFloatArithmetic(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "1.5 + 2",
+			expectedConstants: []interface{}{1.5, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "-0.5",
+			expectedConstants: []interface{}{0.5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
 func TestBooleanExpressions(t *testing.T) {
 	tests := []compilerTestCase{
 		{
@@ -204,6 +286,838 @@ func TestBooleanExpressions(t *testing.T) {
 	runCompilerTests(t, tests)
 } 
 
+func TestLogicalExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "true && false",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthyOrPop, 5),
+				// 0004
+				code.Make(code.OpFalse),
+				// 0005
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "1 || 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpJumpTruthyOrPop, 9),
+				// 0006
+				code.Make(code.OpConstant, 1),
+				// 0009
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestStringExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `"goblin"`,
+			expectedConstants: []interface{}{"goblin"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `"gob" + "lin"`,
+			expectedConstants: []interface{}{"gob", "lin"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `"one ${1 + 1} three"`,
+			expectedConstants: []interface{}{"one ", 1, 1, " three"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpInterpolate, 3),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `if (true) { 10 }; 3333;`,
+			expectedConstants: []interface{}{10, 3333},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 10),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpJump, 11),
+				// 0010
+				code.Make(code.OpNull),
+				// 0011
+				code.Make(code.OpPop),
+				// 0012
+				code.Make(code.OpConstant, 1),
+				// 0015
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `if (true) { 10 } else { 20 }`,
+			expectedConstants: []interface{}{10, 20},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpTrue),
+				// 0001
+				code.Make(code.OpJumpNotTruthy, 10),
+				// 0004
+				code.Make(code.OpConstant, 0),
+				// 0007
+				code.Make(code.OpJump, 13),
+				// 0010
+				code.Make(code.OpConstant, 1),
+				// 0013
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSwitchExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `switch (1) { case 2, 3: 4 default: 5 }`,
+			expectedConstants: []interface{}{1, 2, 3, 4, 5},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpConstant, 0),
+				// 0003
+				code.Make(code.OpDup),
+				// 0004
+				code.Make(code.OpConstant, 1),
+				// 0007
+				code.Make(code.OpEqual),
+				// 0008
+				code.Make(code.OpJumpNotTruthy, 14),
+				// 0011
+				code.Make(code.OpJump, 22),
+				// 0014
+				code.Make(code.OpDup),
+				// 0015
+				code.Make(code.OpConstant, 2),
+				// 0018
+				code.Make(code.OpEqual),
+				// 0019
+				code.Make(code.OpJumpNotTruthy, 29),
+				// 0022
+				code.Make(code.OpPop),
+				// 0023
+				code.Make(code.OpConstant, 3),
+				// 0026
+				code.Make(code.OpJump, 33),
+				// 0029
+				code.Make(code.OpPop),
+				// 0030
+				code.Make(code.OpConstant, 4),
+				// 0033
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestWhileExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `while (false) { 1 }`,
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpLoopEnter),
+				// 0001
+				code.Make(code.OpNull),
+				// 0002
+				code.Make(code.OpFalse),
+				// 0003
+				code.Make(code.OpJumpNotTruthy, 13),
+				// 0006
+				code.Make(code.OpPop),
+				// 0007
+				code.Make(code.OpConstant, 0),
+				// 0010
+				code.Make(code.OpJump, 2),
+				// 0013
+				code.Make(code.OpLoopExit),
+				// 0014
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestForExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `for (k, v in []) { break; continue; }`,
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpArray, 0),
+				// 0003
+				code.Make(code.OpIterInit),
+				// 0004
+				code.Make(code.OpLoopEnter),
+				// 0005
+				code.Make(code.OpIterNext, 32, 2),
+				// 0009
+				code.Make(code.OpClearGlobals, 0, 2),
+				// 0014
+				code.Make(code.OpSetGlobal, 1),
+				// 0017
+				code.Make(code.OpSetGlobal, 0),
+				// 0020
+				code.Make(code.OpUnwind),
+				// 0021
+				code.Make(code.OpPop),
+				// 0022
+				code.Make(code.OpJump, 32),
+				// 0025
+				code.Make(code.OpUnwind),
+				// 0026
+				code.Make(code.OpJump, 5),
+				// 0029
+				code.Make(code.OpJump, 5),
+				// 0032
+				code.Make(code.OpLoopExit),
+				// 0033
+				code.Make(code.OpNull),
+				// 0034
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `for (x in []) { fn() { x } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpArray, 0),
+				// 0003
+				code.Make(code.OpIterInit),
+				// 0004
+				code.Make(code.OpLoopEnter),
+				// 0005
+				code.Make(code.OpIterNext, 28, 1),
+				// 0009
+				code.Make(code.OpClearGlobals, 0, 1),
+				// 0014
+				code.Make(code.OpSetGlobal, 0),
+				// 0017
+				code.Make(code.OpCaptureGlobal, 0),
+				// 0020
+				code.Make(code.OpClosure, 0, 1),
+				// 0024
+				code.Make(code.OpPop),
+				// 0025
+				code.Make(code.OpJump, 5),
+				// 0028
+				code.Make(code.OpLoopExit),
+				// 0029
+				code.Make(code.OpNull),
+				// 0030
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn() { for (x in []) { let y = x; } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					// 0000
+					code.Make(code.OpArray, 0),
+					// 0003
+					code.Make(code.OpIterInit),
+					// 0004
+					code.Make(code.OpLoopEnter),
+					// 0005
+					code.Make(code.OpIterNext, 21, 1),
+					// 0009
+					code.Make(code.OpClearLocals, 0, 2),
+					// 0012
+					code.Make(code.OpSetLocal, 0),
+					// 0014
+					code.Make(code.OpGetLocal, 0),
+					// 0016
+					code.Make(code.OpSetLocal, 1),
+					// 0018
+					code.Make(code.OpJump, 5),
+					// 0021
+					code.Make(code.OpLoopExit),
+					// 0022
+					code.Make(code.OpNull),
+					// 0023
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `while (true) { break; }`,
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				// 0000
+				code.Make(code.OpLoopEnter),
+				// 0001
+				code.Make(code.OpNull),
+				// 0002
+				code.Make(code.OpTrue),
+				// 0003
+				code.Make(code.OpJumpNotTruthy, 16),
+				// 0006
+				code.Make(code.OpPop),
+				// 0007
+				code.Make(code.OpUnwind),
+				// 0008
+				code.Make(code.OpNull),
+				// 0009
+				code.Make(code.OpJump, 16),
+				// 0012
+				code.Make(code.OpNull),
+				// 0013
+				code.Make(code.OpJump, 2),
+				// 0016
+				code.Make(code.OpLoopExit),
+				// 0017
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestRangeExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `1..2`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpNull),
+				code.Make(code.OpRange, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `3 in 1..=5 step 2`,
+			expectedConstants: []interface{}{3, 1, 5, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpRange, 1),
+				code.Make(code.OpIn),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestLetDestructuring(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `let [a, _, ...b] = [1];`,
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpDestructureArray),
+				code.Make(code.OpDup),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpIndex),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpDup),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpNull),
+				code.Make(code.OpNull),
+				code.Make(code.OpSlice),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `let {x} = {};`,
+			expectedConstants: []interface{}{"x"},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpDestructureHash),
+				code.Make(code.OpDup),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpIndex),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `[1][:2]`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpNull),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpNull),
+				code.Make(code.OpSlice),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestGlobalLetStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `let one = 1; let two = 2;`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 1),
+			},
+		},
+		{
+			input: `let one = 1; one;`,
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `let one = 1; let one = 2;`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCollectionLiterals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "[1, 2][0]",
+			expectedConstants: []interface{}{1, 2, 0},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "[1][0] = 2",
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "[1][0] += 2",
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpDupPair),
+				code.Make(code.OpIndex),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "{2: 3, 1: 4}",
+			expectedConstants: []interface{}{1, 4, 2, 3},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpHash, 4),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `fn() { return 5 + 10 }`,
+			expectedConstants: []interface{}{
+				5,
+				10,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn() { }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpReturn),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `let f = fn(a) { len(a) }; f([])`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetBuiltin, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `fn(a, b = 2) { b }`,
+			expectedConstants: []interface{}{
+				2,
+				[]code.Instructions{
+					code.Make(code.OpJumpIfBound, 9, 1),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `let f = fn(a, b) { a }; f(1, b: 2)`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				2,
+				[]string{"b"},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCallNamed, 2, 3),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestSpreadExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `[1, ...[2], 3, 4]`,
+			expectedConstants: []interface{}{1, 2, 3, 4},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSpread),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpArray, 2),
+				code.Make(code.OpSpread),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `{...{}, 1: 2}`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpHash, 0),
+				code.Make(code.OpSpread),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpHash, 2),
+				code.Make(code.OpSpread),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `len(...[1], x: 2)`,
+			expectedConstants: []interface{}{1, 2, []string{"x"}},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpGetBuiltin, 0),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSpread),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpCallSpread, 2),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `fn(a) { fn(b) { a + b } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 0, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn(a) { fn(b) { fn(c) { a + b + c } } }`,
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetFree, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureFree, 0),
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 0, 2),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 1, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: `let x = 1; x = 2;`,
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn() { let x = 1; x -= 2 }`,
+			expectedConstants: []interface{}{
+				1,
+				2,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpSub),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: `fn(x) { fn() { x *= 2 } }`,
+			expectedConstants: []interface{}{
+				2,
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpMul),
+					code.Make(code.OpSetFree, 0),
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpCaptureLocal, 0),
+					code.Make(code.OpClosure, 1, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCompilerErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"x", "identifier not found: x at line 1, column 1"},
+		{"x = 1", "cannot assign to undefined variable x at line 1, column 3"},
+		{"len += 1", "cannot assign to undefined variable len at line 1, column 5"},
+		{"fn() { let y = 1 }; y = 2", "cannot assign to undefined variable y at line 1, column 23"},
+		{"const a = 1; a = 2", "cannot assign to constant a at line 1, column 16"},
+		{"const a = 1; let a = 2", "cannot redeclare constant a at line 1, column 18"},
+		{"const a = 1; const a = 2", "cannot redeclare constant a at line 1, column 20"},
+		{"fn() { const a = 1; fn() { a -= 1 } }", "cannot assign to constant a at line 1, column 30"},
+		{"const a = 1; fn() { a = 2 }", "cannot assign to constant a at line 1, column 23"},
+		{"match ([1, 2]) { [a, 3] => 0, _ => a }", "identifier not found: a at line 1, column 36"},
+		{"match (1) { n => n }; n", "identifier not found: n at line 1, column 23"},
+		{"const a = 1; let [a] = [2]", "cannot redeclare constant a at line 1, column 19"},
+		{"const [a] = [1]; a = 2", "cannot assign to constant a at line 1, column 20"},
+	}
+
+	for _, tt := range tests {
+		program := parse(tt.input)
+
+		err := New().Compile(program)
+		if err == nil {
+			t.Fatalf("expected compiler error for %q but got none", tt.input)
+		}
+
+		if err.Error() != tt.expectedError {
+			t.Errorf("wrong compiler error. expected=%q, got=%q", tt.expectedError, err)
+		}
+	}
+}
+
 func runCompilerTests(t *testing.T, tests []compilerTestCase) {
 	t.Helper()
 
@@ -273,6 +1187,43 @@ func testConstants(t *testing.T, expected []interface{}, actual []object.Object)
 			if err != nil {
 				return fmt.Errorf("constant %d -testIntegerObject failed: %s", i, err)
 			}
+		case *big.Int:
+			err := testBigIntObject(constant, actual[i])
+			if err != nil {
+				return fmt.Errorf("constant %d - testBigIntObject failed: %s", i, err)
+			}
+		case float64:
+			err := testFloatObject(constant, actual[i])
+			if err != nil {
+				return fmt.Errorf("constant %d - testFloatObject failed: %s", i, err)
+			}
+		case string:
+			err := testStringObject(constant, actual[i])
+			if err != nil {
+				return fmt.Errorf("constant %d - testStringObject failed: %s", i, err)
+			}
+		case []string:
+			array, ok := actual[i].(*object.Array)
+			if !ok || len(array.Elements) != len(constant) {
+				return fmt.Errorf("constant %d - not an array of %d elements: %T (%+v)", i, len(constant), actual[i], actual[i])
+			}
+
+			for j, s := range constant {
+				err := testStringObject(s, array.Elements[j])
+				if err != nil {
+					return fmt.Errorf("constant %d - element %d - testStringObject failed: %s", i, j, err)
+				}
+			}
+		case []code.Instructions:
+			fn, ok := actual[i].(*object.CompiledFunction)
+			if !ok {
+				return fmt.Errorf("constant %d - not a function: %T", i, actual[i])
+			}
+
+			err := testInstructions(constant, fn.Instructions)
+			if err != nil {
+				return fmt.Errorf("constant %d - testInstructions failed: %s", i, err)
+			}
 		}
 	}
 
@@ -290,4 +1241,43 @@ func testIntegerObject(expected int64, actual object.Object) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func testStringObject(expected string, actual object.Object) error {
+	result, ok := actual.(*object.String)
+	if !ok {
+		return fmt.Errorf("object is not String. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("object has wrong value. expected=%q, got=%q", expected, result.Value)
+	}
+
+	return nil
+}
+
+func testFloatObject(expected float64, actual object.Object) error {
+	result, ok := actual.(*object.Float)
+	if !ok {
+		return fmt.Errorf("object is not Float. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("object has wrong value. expected=%g, got=%g", expected, result.Value)
+	}
+
+	return nil
+}
+
+func testBigIntObject(expected *big.Int, actual object.Object) error {
+	result, ok := actual.(*object.BigInt)
+	if !ok {
+		return fmt.Errorf("object is not BigInt. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value.Cmp(expected) != 0 {
+		return fmt.Errorf("object has wrong value. expected=%s, got=%s", expected, result.Value)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope SymbolScope = "GLOBAL"
+	LocalScope SymbolScope = "LOCAL"
+	BuiltinScope SymbolScope = "BUILTIN"
+	FreeScope SymbolScope = "FREE"
+)
+
+type Symbol struct {
+	Name string
+	Scope SymbolScope
+	Index int
+	Constant bool
+
+	// Block marks a global defined inside a block, like the body of a for
+	// loop. Closures capture it instead of reading the global directly, so
+	// that each run of the block can bind a variable of its own.
+	Block bool
+}
+
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store map[string]Symbol
+	numDefinitions int
+
+	// FreeSymbols holds the symbols of enclosing scopes, as seen from those
+	// scopes, that this one refers to.
+	FreeSymbols []Symbol
+
+	// blocks holds, for each block being compiled, innermost last, the
+	// bindings that the names defined in it shadow, nil for names that were
+	// not bound before.
+	blocks []map[string]*Symbol
+}
+
+func NewSymbolTable() *SymbolTable {
+	s := make(map[string]Symbol)
+	free := []Symbol{}
+	return &SymbolTable{store: s, FreeSymbols: free}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define binds name in this scope. Defining a name that this scope already
+// defined reuses its slot, the same way a second let overwrites the binding
+// in the evaluator's environment. Inside a block, a name from outside of it
+// gets a new slot instead, which shadows the old one until the block ends.
+func (s *SymbolTable) Define(name string) Symbol {
+	symbol, ok := s.store[name]
+	if ok && (symbol.Scope == GlobalScope || symbol.Scope == LocalScope) && s.inBlock(name) {
+		return symbol
+	}
+
+	if len(s.blocks) > 0 {
+		block := s.blocks[len(s.blocks) - 1]
+		if ok {
+			shadowed := symbol
+			block[name] = &shadowed
+		} else {
+			block[name] = nil
+		}
+	}
+
+	symbol = Symbol{Name: name, Index: s.numDefinitions}
+	if s.Outer == nil {
+		symbol.Scope = GlobalScope
+		symbol.Block = len(s.blocks) > 0
+	} else {
+		symbol.Scope = LocalScope
+	}
+
+	s.store[name] = symbol
+	s.numDefinitions++
+	return symbol
+}
+
+// enterBlock starts a block, like the arm of a match, whose bindings are
+// not seen outside of it.
+func (s *SymbolTable) enterBlock() {
+	s.blocks = append(s.blocks, map[string]*Symbol{})
+}
+
+// leaveBlock ends the innermost block and brings back the bindings that its
+// definitions shadowed.
+func (s *SymbolTable) leaveBlock() {
+	block := s.blocks[len(s.blocks) - 1]
+	s.blocks = s.blocks[:len(s.blocks) - 1]
+
+	for name, shadowed := range block {
+		if shadowed == nil {
+			delete(s.store, name)
+		} else {
+			s.store[name] = *shadowed
+		}
+	}
+}
+
+// inBlock reports whether name was defined in the innermost block, which
+// outside of any block is the scope itself.
+func (s *SymbolTable) inBlock(name string) bool {
+	if len(s.blocks) == 0 {
+		return true
+	}
+
+	_, ok := s.blocks[len(s.blocks) - 1][name]
+	return ok
+}
+
+// hide undefines names in this scope until the returned function is called,
+// for code that must not see them yet.
+func (s *SymbolTable) hide(names []string) func() {
+	hidden := map[string]Symbol{}
+	for _, name := range names {
+		if symbol, ok := s.store[name]; ok {
+			hidden[name] = symbol
+			delete(s.store, name)
+		}
+	}
+
+	return func() {
+		for name, symbol := range hidden {
+			s.store[name] = symbol
+		}
+	}
+}
+
+// DefineConstant binds name like Define and marks it as not assignable.
+func (s *SymbolTable) DefineConstant(name string) Symbol {
+	symbol := s.Define(name)
+	symbol.Constant = true
+	s.store[name] = symbol
+	return symbol
+}
+
+// IsConstant reports whether this scope itself, ignoring the outer ones and
+// the blocks around the current one, defines name as a constant.
+func (s *SymbolTable) IsConstant(name string) bool {
+	symbol, ok := s.store[name]
+	return ok && symbol.Constant && symbol.Scope != FreeScope && s.inBlock(name)
+}
+
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	symbol := Symbol{Name: name, Index: index, Scope: BuiltinScope}
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1}
+	symbol.Scope = FreeScope
+	symbol.Constant = original.Constant
+
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	if !ok && s.Outer != nil {
+		symbol, ok = s.Outer.Resolve(name)
+		if !ok {
+			return symbol, ok
+		}
+
+		if (symbol.Scope == GlobalScope && !symbol.Block) || symbol.Scope == BuiltinScope {
+			return symbol, ok
+		}
+
+		return s.defineFree(symbol), true
+	}
+
+	return symbol, ok
+}
@@ -0,0 +1,154 @@
+package compiler
+
+import "testing"
+
+func TestDefine(t *testing.T) {
+	expected := map[string]Symbol{
+		"a": {Name: "a", Scope: GlobalScope, Index: 0},
+		"b": {Name: "b", Scope: GlobalScope, Index: 1},
+		"c": {Name: "c", Scope: LocalScope, Index: 0},
+		"d": {Name: "d", Scope: LocalScope, Index: 1},
+	}
+
+	global := NewSymbolTable()
+
+	a := global.Define("a")
+	if a != expected["a"] {
+		t.Errorf("expected a=%+v, got=%+v", expected["a"], a)
+	}
+
+	b := global.Define("b")
+	if b != expected["b"] {
+		t.Errorf("expected b=%+v, got=%+v", expected["b"], b)
+	}
+
+	local := NewEnclosedSymbolTable(global)
+
+	c := local.Define("c")
+	if c != expected["c"] {
+		t.Errorf("expected c=%+v, got=%+v", expected["c"], c)
+	}
+
+	d := local.Define("d")
+	if d != expected["d"] {
+		t.Errorf("expected d=%+v, got=%+v", expected["d"], d)
+	}
+
+	again := global.Define("a")
+	if again != expected["a"] {
+		t.Errorf("redefining a should reuse %+v, got=%+v", expected["a"], again)
+	}
+}
+
+func TestResolveFree(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+	global.DefineBuiltin(0, "len")
+
+	outer := NewEnclosedSymbolTable(global)
+	outer.Define("b")
+
+	inner := NewEnclosedSymbolTable(outer)
+	inner.Define("c")
+
+	expected := []Symbol{
+		{Name: "a", Scope: GlobalScope, Index: 0},
+		{Name: "len", Scope: BuiltinScope, Index: 0},
+		{Name: "b", Scope: FreeScope, Index: 0},
+		{Name: "c", Scope: LocalScope, Index: 0},
+	}
+
+	for _, sym := range expected {
+		result, ok := inner.Resolve(sym.Name)
+		if !ok {
+			t.Errorf("name %s not resolvable", sym.Name)
+			continue
+		}
+		if result != sym {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", sym.Name, sym, result)
+		}
+	}
+
+	if len(inner.FreeSymbols) != 1 || inner.FreeSymbols[0] != (Symbol{Name: "b", Scope: LocalScope, Index: 0}) {
+		t.Errorf("wrong free symbols. got=%+v", inner.FreeSymbols)
+	}
+
+	shadow := inner.Define("b")
+	if shadow != (Symbol{Name: "b", Scope: LocalScope, Index: 1}) {
+		t.Errorf("defining a captured name should shadow it. got=%+v", shadow)
+	}
+
+	if _, ok := inner.Resolve("x"); ok {
+		t.Errorf("name x resolved, but was never defined")
+	}
+}
+
+func TestDefineConstant(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	a := global.DefineConstant("a")
+	if a != (Symbol{Name: "a", Scope: GlobalScope, Index: 0, Constant: true}) {
+		t.Errorf("a should keep its slot and become constant. got=%+v", a)
+	}
+
+	if !global.IsConstant("a") {
+		t.Errorf("a is not reported as constant")
+	}
+
+	local := NewEnclosedSymbolTable(NewEnclosedSymbolTable(global))
+	local.Outer.DefineConstant("b")
+
+	b, ok := local.Resolve("b")
+	if !ok || b.Scope != FreeScope || !b.Constant {
+		t.Errorf("free b should stay constant. got=%+v", b)
+	}
+
+	if local.IsConstant("b") {
+		t.Errorf("b is only constant in the outer scope")
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	global := NewSymbolTable()
+	outer := global.DefineConstant("a")
+
+	global.enterBlock()
+
+	if global.IsConstant("a") {
+		t.Errorf("a from outside the block should not count as a constant in it")
+	}
+
+	shadow := global.Define("a")
+	expected := Symbol{Name: "a", Scope: GlobalScope, Index: 1, Block: true}
+	if shadow != expected {
+		t.Errorf("expected a=%+v in the block, got=%+v", expected, shadow)
+	}
+
+	again := global.Define("a")
+	if again != expected {
+		t.Errorf("redefining a in the block should reuse %+v, got=%+v", expected, again)
+	}
+
+	global.Define("b")
+
+	local := NewEnclosedSymbolTable(global)
+	if b, _ := local.Resolve("b"); b.Scope != FreeScope {
+		t.Errorf("a global of a block should be captured as a free variable. got=%+v", b)
+	}
+
+	global.leaveBlock()
+
+	local = NewEnclosedSymbolTable(global)
+	if a, _ := local.Resolve("a"); a != outer {
+		t.Errorf("a global outside of any block should be read directly. got=%+v", a)
+	}
+
+	if a, _ := global.Resolve("a"); a != outer {
+		t.Errorf("expected a=%+v after the block, got=%+v", outer, a)
+	}
+
+	if b, ok := global.Resolve("b"); ok {
+		t.Errorf("b should not resolve after the block, got=%+v", b)
+	}
+}
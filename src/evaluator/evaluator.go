@@ -4,115 +4,18 @@ import (
 	"fmt"
 	"goblin/ast"
 	"goblin/object"
-	"math"
+	"goblin/token"
+	"strings"
 )
 
 var (
 	NULL = &object.Null{}
 	TRUE = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
+	BREAK = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
-var builtins = map[string]*object.Builtin {
-	"len": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
-			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
-			default:
-				return newError("argument to `len` not supported. got %s", args[0].Type())
-			}
-		},
-	},
-	"first": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				if len(arg.Elements) > 0 {
-					return arg.Elements[0]
-				}
-				return NULL
-			default:
-				return newError("argument to `first` not supported. got %s", args[0].Type())
-			}
-		},
-	},
-	"last": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				length := len(arg.Elements)
-				if length > 0 {
-					return arg.Elements[length - 1]
-				}
-				return NULL
-			default:
-				return newError("argument to `last` not supported. got %s", args[0].Type())
-			}
-		},
-	},
-	"rest": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				length := len(arg.Elements)
-				if length > 0 {
-					newElements := make([]object.Object, length-1)
-					copy(newElements, arg.Elements[1:length])
-					return &object.Array{Elements: newElements}
-				}
-				return NULL
-			default:
-				return newError("argument to `rest` not supported. got %s", args[0].Type())
-			}
-		},
-	},
-	"push": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				length := len(arg.Elements)
-				newElements := make([]object.Object, length + 1)
-				copy(newElements, arg.Elements)
-				newElements[length] = args[1]
-				return &object.Array{Elements: newElements}
-			default:
-				return newError("argument to `push` not supported. got %s", args[0].Type())
-			}
-		},
-	},
-	"puts": {
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
-			return NULL
-		},
-	},
-}
-
 func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type){
 	// Statements
@@ -124,82 +27,132 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalBlockStatement(node, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		if isUnwinding(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	// Expressions
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.BigIntLiteral:
+		return &object.BigInt{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isUnwinding(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if isUnwinding(left) {
 			return left
 		}
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isUnwinding(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return evalInfixExpression(node.Token, node.Operator, left, right)
+	case *ast.RangeExpression:
+		return evalRangeExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.IndexAssignExpression:
+		return evalIndexAssignExpression(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.SwitchExpression:
+		return evalSwitchExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	case *ast.WhileExpression:
 		return evalWhileExpression(node, env)
+	case *ast.ForExpression:
+		return evalForExpression(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.LetStatement:
+		if node.Pattern != nil {
+			val := Eval(node.Value, env)
+			if isUnwinding(val) {
+				return val
+			}
+
+			return destructure(node.Pattern, val, node.IsConst(), env)
+		}
+
+		if env.DefinesConstant(node.Name.Value) {
+			return newPositionedError(node.Name.Token, "cannot redeclare constant %s", node.Name.Value)
+		}
+
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if isUnwinding(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+
+		if node.IsConst() {
+			env.SetConstant(node.Name.Value, val)
+		} else {
+			env.Set(node.Name.Value, val)
+		}
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Name: node.Name, Parameters: params, Defaults: node.Defaults, Rest: node.Rest, Body: body, Env: env}
+	case *ast.NamedArgument:
+		// The call looks up the names separately, so only the value is
+		// evaluated here.
+		return Eval(node.Value, env)
 	case *ast.CallExpression:
 		if node.Function.TokenLiteral() == "quote" {
 			return quote(node.Arguments[0], env)
 		}
 
 		function := Eval(node.Function, env)
-		if isError(function) {
+		if isUnwinding(function) {
 			return function
 		}
 		
 		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
+		if len(args) == 1 && isUnwinding(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		return applyFunction(node.Token, function, args, node.ArgumentNames())
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
+		if len(elements) == 1 && isUnwinding(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if isUnwinding(left) {
 			return left
 		}
 		index := Eval(node.Index, env)
-		if isError(index) {
+		if isUnwinding(index) {
 			return index
 		}
 
-		return evalIndexExpression(left, index)
+		return evalIndexExpression(node.Token, left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	}
@@ -231,8 +184,8 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 		result = Eval(statement, env)
 
 		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return result
 			}
 		} 
@@ -254,6 +207,8 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)	
+	case "~":
+		return evalBitNotOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -273,18 +228,34 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer, *object.BigInt:
+		return object.NegateInteger(right)
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
+}
+
+func evalBitNotOperatorExpression(right object.Object) object.Object {
+	if !object.IsInteger(right) {
+		return newError("unknown operator: ~%s", right.Type())
+	}
 
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+	return object.InvertInteger(right)
 }
 
-func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+func evalInfixExpression(tok token.Token, operator string, left object.Object, right object.Object) object.Object {
 	switch {
+	case operator == "in":
+		return evalInExpression(tok, left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+		return evalIntegerInfixExpression(tok, operator, left, right)
+	case object.IsInteger(left) && object.IsInteger(right):
+		return evalBigIntInfixExpression(tok, operator, left, right)
+	case object.IsNumber(left) && object.IsNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
@@ -298,21 +269,132 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+func evalInExpression(tok token.Token, item, container object.Object) object.Object {
+	found, err := object.Contains(container, item)
+	if err != nil {
+		return newPositionedError(tok, "%s", err)
+	}
+
+	return nativeBoolToBooleanObject(found)
+}
+
+func evalRangeExpression(node *ast.RangeExpression, env *object.Environment) object.Object {
+	start := Eval(node.Start, env)
+	if isUnwinding(start) {
+		return start
+	}
+
+	end := Eval(node.End, env)
+	if isUnwinding(end) {
+		return end
+	}
+
+	var step object.Object
+	if node.Step != nil {
+		step = Eval(node.Step, env)
+		if isUnwinding(step) {
+			return step
+		}
+	}
+
+	r, err := object.NewRange(start, end, step, node.IsInclusive())
+	if err != nil {
+		return newPositionedError(node.Token, "%s", err)
+	}
+
+	return r
+}
+
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isUnwinding(left) {
+		return left
+	}
+
+	if isTruthy(left) == (node.Operator == "||") {
+		return left
+	}
+
+	return Eval(node.Right, env)
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	name := node.Name.Value
+
+	current, ok := env.Get(name)
+	if !ok {
+		return newPositionedError(node.Token, "cannot assign to undefined variable %s", name)
+	}
+
+	if env.IsConstant(name) {
+		return newPositionedError(node.Token, "cannot assign to constant %s", name)
+	}
+
+	val := Eval(node.Value, env)
+	if isUnwinding(val) {
+		return val
+	}
+
+	if node.Operator != "=" {
+		val = evalInfixExpression(node.Token, strings.TrimSuffix(node.Operator, "="), current, val)
+		if isUnwinding(val) {
+			return val
+		}
+	}
+
+	env.Assign(name, val)
+	return val
+}
+
+func evalIndexAssignExpression(node *ast.IndexAssignExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isUnwinding(left) {
+		return left
+	}
+
+	index := Eval(node.Index, env)
+	if isUnwinding(index) {
+		return index
+	}
+
+	val := Eval(node.Value, env)
+	if isUnwinding(val) {
+		return val
+	}
+
+	// A compound assignment reads the element through the same left and
+	// index it writes, so neither is evaluated twice.
+	if node.Operator != "=" {
+		current := evalIndexExpression(node.Token, left, index)
+		if isUnwinding(current) {
+			return current
+		}
+
+		val = evalInfixExpression(node.Token, strings.TrimSuffix(node.Operator, "="), current, val)
+		if isUnwinding(val) {
+			return val
+		}
+	}
+
+	err := object.SetIndex(left, index, val)
+	if err != nil {
+		return newPositionedError(node.Token, "%s", err)
+	}
+
+	return val
+}
+
+func evalIntegerInfixExpression(tok token.Token, operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
 	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
-	case "**":
-		return &object.Integer{Value: int64(math.Pow(float64(leftVal), float64(rightVal)))}
+	case "+", "-", "*", "/", "**", "%", "&", "|", "^", "<<", ">>":
+		result, err := object.IntegerArithmetic(operator, leftVal, rightVal)
+		if err != nil {
+			return newPositionedError(tok, "%s", err)
+		}
+		return result
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
 	case "<":
@@ -330,20 +412,97 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
-func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	if operator != "+" {
+func evalBigIntInfixExpression(tok token.Token, operator string, left object.Object, right object.Object) object.Object {
+	switch operator {
+	case "+", "-", "*", "/", "**", "%", "&", "|", "^", "<<", ">>":
+		leftVal, _ := object.ToBigInt(left)
+		rightVal, _ := object.ToBigInt(right)
+
+		result, err := object.BigIntArithmetic(operator, leftVal, rightVal)
+		if err != nil {
+			return newPositionedError(tok, "%s", err)
+		}
+		return result
+	case ">":
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) > 0)
+	case "<":
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) < 0)
+	case ">=":
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) >= 0)
+	case "<=":
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) <= 0)
+	case "==":
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(object.CompareIntegers(left, right) != 0)
+	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
+}
+
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal, _ := object.ToFloat(left)
+	rightVal, _ := object.ToFloat(right)
 
+	switch operator {
+	case "+", "-", "*", "/", "**", "%":
+		result, err := object.FloatArithmetic(operator, leftVal, rightVal)
+		if err != nil {
+			return newError("%s", err)
+		}
+		return result
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		evaluated := Eval(part, env)
+		if isUnwinding(evaluated) {
+			return evaluated
+		}
+
+		out.WriteString(evaluated.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
 }
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
-	if isError(condition) {
+	if isUnwinding(condition) {
 		return condition
 	}
 
@@ -360,7 +519,7 @@ func evalWhileExpression(ie *ast.WhileExpression, env *object.Environment) objec
 	var condition object.Object
 	condition = Eval(ie.Condition, env)
 
-	if isError(condition) {
+	if isUnwinding(condition) {
 		return condition
 	}
 
@@ -369,12 +528,274 @@ func evalWhileExpression(ie *ast.WhileExpression, env *object.Environment) objec
 
 	for isTruthy(condition) {
 		result = Eval(ie.Loop, env)
+		if result != nil {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+				return result
+			case object.BREAK_OBJ:
+				return NULL
+			case object.CONTINUE_OBJ:
+				result = NULL
+			}
+		}
+
 		condition = Eval(ie.Condition, env)
-	} 
+		if isUnwinding(condition) {
+			return condition
+		}
+	}
 	
 	return result
 }
 
+func evalSwitchExpression(se *ast.SwitchExpression, env *object.Environment) object.Object {
+	value := Eval(se.Value, env)
+	if isUnwinding(value) {
+		return value
+	}
+
+	for _, c := range se.Cases {
+		for _, v := range c.Values {
+			candidate := Eval(v, env)
+			if isUnwinding(candidate) {
+				return candidate
+			}
+
+			if isTruthy(evalInfixExpression(c.Token, "==", value, candidate)) {
+				return evalCaseBody(c.Body, env)
+			}
+		}
+	}
+
+	return evalCaseBody(se.Default, env)
+}
+
+// evalMatchExpression binds the names of a pattern in env, like a let
+// would, before its guard runs. A match without a fitting arm is null.
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	value := Eval(me.Value, env)
+	if isUnwinding(value) {
+		return value
+	}
+
+	for _, arm := range me.Arms {
+		// Each arm binds its names in an environment of its own, so that
+		// neither a failed arm nor the chosen one changes the variables
+		// around the match.
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, value, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isUnwinding(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return evalCaseBody(arm.Body, armEnv)
+	}
+
+	return NULL
+}
+
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.BindingPattern:
+		return true, bindPatternName(pattern.Name, value, false, env)
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		if isUnwinding(literal) {
+			return false, literal
+		}
+		return isTruthy(evalInfixExpression(token.Token{}, "==", value, literal)), nil
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+
+		n := len(pattern.Elements)
+		if len(array.Elements) < n || (!pattern.HasRest && len(array.Elements) != n) {
+			return false, nil
+		}
+
+		for i, el := range pattern.Elements {
+			matched, err := matchPattern(el, array.Elements[i], env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := append([]object.Object{}, array.Elements[n:]...)
+			return true, bindPatternName(pattern.Rest, &object.Array{Elements: rest}, false, env)
+		}
+		return true, nil
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+
+		for _, pair := range pattern.Pairs {
+			key := Eval(pair.Key, env)
+			if isUnwinding(key) {
+				return false, key
+			}
+
+			found, ok := hash.Pairs[key.(object.Hashable).HashKey()]
+			if !ok {
+				return false, nil
+			}
+
+			matched, err := matchPattern(pair.Value, found.Value, env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	default:
+		return false, newError("unknown pattern: %s", pattern.String())
+	}
+}
+
+// destructure binds the names of a let pattern to the parts of value. Unlike
+// a match it cannot fail on a short array or a missing key, which bind null,
+// only on a value of the wrong type.
+func destructure(pattern ast.Pattern, value object.Object, constant bool, env *object.Environment) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		return bindPatternName(pattern.Name, value, constant, env)
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return newPositionedError(pattern.Token, "cannot destructure %s as ARRAY", value.Type())
+		}
+
+		for i, el := range pattern.Elements {
+			var element object.Object = NULL
+			if i < len(array.Elements) {
+				element = array.Elements[i]
+			}
+
+			err := destructure(el, element, constant, env)
+			if err != nil {
+				return err
+			}
+		}
+
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if n := len(pattern.Elements); n < len(array.Elements) {
+				rest = append(rest, array.Elements[n:]...)
+			}
+			return bindPatternName(pattern.Rest, &object.Array{Elements: rest}, constant, env)
+		}
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return newPositionedError(pattern.Token, "cannot destructure %s as HASH", value.Type())
+		}
+
+		for _, pair := range pattern.Pairs {
+			key := Eval(pair.Key, env)
+			if isUnwinding(key) {
+				return key
+			}
+
+			var element object.Object = NULL
+			if found, ok := hash.Pairs[key.(object.Hashable).HashKey()]; ok {
+				element = found.Value
+			}
+
+			err := destructure(pair.Value, element, constant, env)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// bindPatternName returns an error, or nil once name is bound.
+func bindPatternName(name *ast.Identifier, value object.Object, constant bool, env *object.Environment) object.Object {
+	if env.DefinesConstant(name.Value) {
+		return newPositionedError(name.Token, "cannot redeclare constant %s", name.Value)
+	}
+
+	if constant {
+		env.SetConstant(name.Value, value)
+	} else {
+		env.Set(name.Value, value)
+	}
+	return nil
+}
+
+// evalCaseBody yields null for a missing default and for an empty body.
+func evalCaseBody(body *ast.BlockStatement, env *object.Environment) object.Object {
+	if body == nil {
+		return NULL
+	}
+
+	result := Eval(body, env)
+	if result == nil {
+		return NULL
+	}
+
+	return result
+}
+
+// evalForExpression runs each iteration in an environment of its own, where
+// the loop variables and the lets of the body are bound afresh. That keeps
+// them from overwriting variables outside the loop and gives the closures
+// of every iteration their own. The loop itself evaluates to null.
+func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Object {
+	iterable := Eval(fe.Iterable, env)
+	if isUnwinding(iterable) {
+		return iterable
+	}
+
+	iterator, err := object.NewIterator(iterable)
+	if err != nil {
+		return newPositionedError(fe.Token, "%s", err)
+	}
+
+	for {
+		values, ok := iterator.Next(len(fe.Names))
+		if !ok {
+			return NULL
+		}
+
+		iterationEnv := object.NewEnclosedEnvironment(env)
+		for i, name := range fe.Names {
+			iterationEnv.Set(name.Value, values[i])
+		}
+
+		result := Eval(fe.Body, iterationEnv)
+		if result != nil {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+				return result
+			case object.BREAK_OBJ:
+				return NULL
+			}
+		}
+	}
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL, FALSE:
@@ -388,6 +809,25 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+func newPositionedError(tok token.Token, format string, a ...interface{}) *object.Error {
+	msg := fmt.Sprintf(format, a...)
+	return newError("%s at line %d, column %d", msg, tok.Line, tok.Column)
+}
+
+// isUnwinding reports whether obj is an error, or a break or continue on
+// its way out to the enclosing loop. Expressions pass either on unchanged
+// instead of using it as a value.
+func isUnwinding(obj object.Object) bool {
+	if obj != nil {
+		switch obj.Type() {
+		case object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+			return true
+		}
+	}
+
+	return false
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -401,19 +841,34 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
-	if builtin, ok := builtins[node.Value]; ok {
+	if builtin := object.GetBuiltinByName(node.Value); builtin != nil {
 		return builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	return newPositionedError(node.Token, "identifier not found: %s", node.Value)
 }
 
 func evalExpressions( exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, e := range exps {
+		if spread, ok := e.(*ast.SpreadExpression); ok {
+			value := Eval(spread.Value, env)
+			if isUnwinding(value) {
+				return []object.Object{value}
+			}
+
+			array := &object.Array{Elements: result}
+			err := object.Spread(array, value)
+			if err != nil {
+				return []object.Object{newPositionedError(spread.Token, "%s", err)}
+			}
+			result = array.Elements
+			continue
+		}
+
 		evaluated := Eval(e, env)
-		if isError(evaluated) {
+		if isUnwinding(evaluated) {
 			return []object.Object{evaluated}
 		}
 
@@ -423,28 +878,69 @@ func evalExpressions( exps []ast.Expression, env *object.Environment) []object.O
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+func applyFunction(tok token.Token, fn object.Object, args []object.Object, names []string) object.Object {
 
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
+		values, err := object.NewArity(fn.Parameters, fn.Defaults, fn.Rest).Bind(args, names)
+		if err != nil {
+			return newPositionedError(tok, "%s", err)
+		}
+
+		extendedEnv, errObj := extendFunctionEnv(fn, values)
+		if errObj != nil {
+			return traceError(errObj, fn, tok)
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
+		if isError(evaluated) {
+			return traceError(evaluated, fn, tok)
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		return fn.Fn(args...)
+		if len(names) > 0 {
+			return newPositionedError(tok, "builtin functions do not take named arguments")
+		}
+		result := fn.Fn(args...)
+		if result == nil {
+			return NULL
+		}
+		if errObj, ok := result.(*object.Error); ok {
+			return newPositionedError(tok, "%s", errObj.Message)
+		}
+		return result
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newPositionedError(tok, "not a function: %s", fn.Type())
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// traceError adds the call of fn at tok to the trace of an error raised
+// while fn ran.
+func traceError(err object.Object, fn *object.Function, tok token.Token) object.Object {
+	return &object.Error{Message: object.Trace(err.(*object.Error).Message, fn.Name, tok)}
+}
+
+// extendFunctionEnv binds the parameters to the values Arity.Bind lined up
+// for them. A missing value is filled in by evaluating the default in the
+// new environment, so it sees the parameters before it.
+func extendFunctionEnv(fn *object.Function, values []object.Object) (*object.Environment, object.Object) {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		value := values[paramIdx]
+		if value == nil {
+			value = Eval(fn.Defaults[paramIdx], env)
+			if isError(value) {
+				return nil, value
+			}
+		}
+		env.Set(param.Value, value)
 	}
 
-	return env
+	if fn.Rest != nil {
+		env.Set(fn.Rest.Value, values[len(fn.Parameters)])
+	}
+
+	return env, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -455,45 +951,113 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
-func evalIndexExpression(left, index object.Object) object.Object {
+func evalIndexExpression(tok token.Token, left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.RANGE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalRangeIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
-		return evalHashIndexExpression(left, index)
+		return evalHashIndexExpression(tok, left, index)
+	case index.Type() == object.RANGE_OBJ:
+		sliced, err := object.Slice(left, index.(*object.Range))
+		if err != nil {
+			return newPositionedError(tok, "%s", err)
+		}
+		return sliced
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newPositionedError(tok, "index operator not supported: %s", left.Type())
 	}
 }
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
+	idx, ok := object.ResolveIndex(index.(*object.Integer).Value, int64(len(arrayObject.Elements)))
 
-	if idx < 0 || idx > max {
+	if !ok {
 		return NULL
 	}
 
 	return arrayObject.Elements[idx]
 }
 
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, ok := object.ResolveIndex(index.(*object.Integer).Value, int64(len(runes)))
+
+	if !ok {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isUnwinding(left) {
+		return left
+	}
+
+	bounds := []object.Object{NULL, NULL, NULL}
+	for i, bound := range []ast.Expression{node.Start, node.End, node.Step} {
+		if bound == nil {
+			continue
+		}
+
+		bounds[i] = Eval(bound, env)
+		if isUnwinding(bounds[i]) {
+			return bounds[i]
+		}
+	}
+
+	sliced, err := object.SliceBetween(left, bounds[0], bounds[1], bounds[2])
+	if err != nil {
+		return newPositionedError(node.Token, "%s", err)
+	}
+
+	return sliced
+}
+
+func evalRangeIndexExpression(r, index object.Object) object.Object {
+	rangeObject := r.(*object.Range)
+	value, ok := rangeObject.Index(index.(*object.Integer).Value)
+	if !ok {
+		return NULL
+	}
+
+	return &object.Integer{Value: value}
+}
+
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.HashKey]object.HashPair)
 
+	for _, spread := range node.Spreads {
+		value := Eval(spread.Value, env)
+		if isUnwinding(value) {
+			return value
+		}
+
+		err := object.Spread(&object.Hash{Pairs: pairs}, value)
+		if err != nil {
+			return newPositionedError(spread.Token, "%s", err)
+		}
+	}
+
 	for keyNode, valueNode := range node.Pairs {
 		key := Eval(keyNode, env)
-		if isError(key) {
+		if isUnwinding(key) {
 			return key
 		}
 
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return newPositionedError(node.Token, "unusable as hash key: %s", key.Type())
 		}
 
 		value := Eval(valueNode, env)
-		if isError(value) {
+		if isUnwinding(value) {
 			return value
 		}
 
@@ -504,11 +1068,11 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 	return &object.Hash{Pairs: pairs}
 }
 
-func evalHashIndexExpression(hash, index object.Object) object.Object {
+func evalHashIndexExpression(tok token.Token, hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newPositionedError(tok, "unusable as hash key: %s", index.Type())
 	}
 
 	pair, ok := hashObject.Pairs[key.HashKey()]
@@ -49,6 +49,18 @@ func convertObjectToASTNode(obj object.Object) ast.Node {
 			Literal: fmt.Sprintf("%d", obj.Value),
 		}
 		return &ast.IntegerLiteral{Token: t, Value: obj.Value}
+	case *object.BigInt:
+		t := token.Token{
+			Type: token.INT,
+			Literal: obj.Inspect(),
+		}
+		return &ast.BigIntLiteral{Token: t, Value: obj.Value}
+	case *object.Float:
+		t := token.Token{
+			Type: token.FLOAT,
+			Literal: obj.Inspect(),
+		}
+		return &ast.FloatLiteral{Token: t, Value: obj.Value}
 	case *object.Boolean:
 		var t token.Token
 		if obj.Value {
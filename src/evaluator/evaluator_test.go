@@ -5,6 +5,7 @@ import (
 	"goblin/lexer"
 	"goblin/object"
 	"goblin/parser"
+	"strings"
 	"testing"
 )
 
@@ -34,6 +35,22 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"5 ** 2", 25},
 		{"5 ** 3", 125},
 		{"5 * 2 ** 2", 20},
+		{"2 ** 3 ** 2", 512},
+		{"3 ** 39", 4052555153018976267},
+		{"2 ** 62 + (2 ** 62 - 1)", 9223372036854775807},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"~5", -6},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"1 << 63 >> 63", 1},
+		{"(2 ** 64 | 1) & 3", 1},
+		{"~(2 ** 64) + 2 ** 64", -1},
+		{"0 ** (2 ** 64)", 0},
+		{"(-1) ** (2 ** 64 + 1)", -1},
 	}
 
 	for _, tt := range tests {
@@ -42,6 +59,116 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalBigIntExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"2 ** 64", "18446744073709551616"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"123456789012345678901234567890 * 10 / 10", "123456789012345678901234567890"},
+		{"2 ** 64 - 2 ** 64 + 5", 5},
+		{"(2 ** 63) / 2", 4611686018427387904},
+		{"2 ** 64 > 9223372036854775807", true},
+		{"2 ** 64 == 18446744073709551616", true},
+		{"2 ** 64 < 1", false},
+		{"2 ** 64 > 1.5", true},
+		{"float(2 ** 64)", 18446744073709551616.0},
+		{"int(1e30)", "1000000000000000019884624838656"},
+		{`int("123456789012345678901234567890")`, "123456789012345678901234567890"},
+		{`{2 ** 64: "big"}[18446744073709551616]`, "big"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanobject(t, evaluated, expected)
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong value for %q. expected=%s, got=%s", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		expected float64
+	}{
+		{"2.5", 2.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2},
+		{"7 / 2.0", 3.5},
+		{"10.0 - 2", 8},
+		{"2.0 ** 0.5 * 2.0 ** 0.5", 2.0000000000000004},
+		{"2 ** -1.0", 0.5},
+		{"2 ** -2", 0.25},
+		{"7.5 % 2", 1.5},
+		{"float(3)", 3},
+		{`float("2.25")`, 2.25},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestNumericConversions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"int(3.99)", 3},
+		{"int(-3.99)", -3},
+		{"int(7)", 7},
+		{`int("0x10")`, 16},
+		{`int(" 42 ")`, 42},
+		{"1.5 > 1", true},
+		{"1 == 1.0", true},
+		{"2 <= 1.5", false},
+		{"0.1 + 0.2 == 0.3", false},
+		{`int("nope")`, `could not parse "nope" as integer at line 1, column 4`},
+		{`float("nope")`, `could not parse "nope" as float at line 1, column 6`},
+		{"int(1.0 / 0.0)", "float +Inf cannot be converted to an integer at line 1, column 4"},
+		{"int(true)", "argument to `int` not supported. got BOOLEAN at line 1, column 4"},
+		{"float([])", "argument to `float` not supported. got ARRAY at line 1, column 6"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanobject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q. got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestEvalStringExpressions(t *testing.T) {
 	tests := []struct {
 		input string
@@ -52,6 +179,12 @@ func TestEvalStringExpressions(t *testing.T) {
 		{`"newline\n"`, "newline\n"},
 		{`"\ttab"`, "\ttab"},
 		{`"foo" + "bar"`, "foobar"},
+		{`let name = "goblin"; "hello ${name}"`, "hello goblin"},
+		{`let items = [1, 2]; "${len(items)} items: ${items}"`, "2 items: [1, 2]"},
+		{`"${1 + 2} ${true} ${"nested ${"inner"}"}"`, "3 true nested inner"},
+		{`"\${name}"`, "${name}"},
+		{"`raw ${name} \\n`", "raw ${name} \\n"},
+		{"\"\"\"\n  one\n    two\n  \"\"\" + \"!\"", "one\n  two!"},
 	}
 
 	for _, tt := range tests {
@@ -91,6 +224,9 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 <= 2) == false", false},
 		{"(1 >= 2) == true", false},
 		{"(1 >= 2) == false", true},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{`"a" == "b"`, false},
 	}
 
 	for _, tt := range tests {
@@ -99,6 +235,36 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 2", 2},
+		{"false && 2", false},
+		{"1 || 2", 1},
+		{"false || 2", 2},
+		{"let x = 5; x > 1 && x < 10", true},
+		{"false && 1 / 0", false},
+		{"true || 1 / 0", true},
+		{"if (1 || 1 / 0) { 10 }", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanobject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestBangOperator(t* testing.T) {
 	tests := []struct{
 		input string
@@ -130,6 +296,10 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 } else { 30 }", 30},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 }", nil},
+		{"if (false) { 1 } else if (false) { 2 } else if (true) { 3 } else { 4 }", 3},
 	}
 
 	for _, tt := range tests {
@@ -143,6 +313,158 @@ func TestIfElseExpressions(t *testing.T) {
 	}
 }
 
+func TestSwitchExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"switch (2) { case 1: 10 case 2: 20 default: 30 }", 20},
+		{"switch (5) { case 1: 10 case 2: 20 default: 30 }", 30},
+		{"switch (5) { case 1: 10 }", nil},
+		{"switch (3) { case 1, 2: 10 case 3, 4: 20 }", 20},
+		{`switch ("b") { case "a": 1 case "b": 2 }`, 2},
+		{"switch (2.0) { case 1: 1 case 2: 2 }", 2},
+		{"switch (1) { case 1: }", nil},
+		{"let x = 0; switch (1) { case 1: x = 5; x += 1; case 2: x = 100 }; x", 6},
+		{"let f = fn(n) { switch (n) { case 0: return 100; default: n } }; f(0) + f(1)", 101},
+		{"let s = 0; for (i in 0..5) { switch (i) { case 2: continue; case 4: break; } s += i }; s", 4},
+		{"switch (1) { case 1 / 0: 1 }", "division by zero: 1 / 0 at line 1, column 21"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"match (1) { 1 => 10, _ => 20 }", 10},
+		{"match (2) { 1 => 10, _ => 20 }", 20},
+		{"match (3) { 1 => 10 }", nil},
+		{"match (-1) { -1 => 10, _ => 20 }", 10},
+		{`match ("b") { "a" => 1, "b" => 2, _ => 3 }`, 2},
+		{"match (true) { false => 1, true => 2 }", 2},
+		{"match (5) { n => n * 2 }", 10},
+		{"match (5) { n if n > 10 => 1, n if n > 0 => 2, _ => 3 }", 2},
+		{"match (-5) { n if n > 10 => 1, n if n > 0 => 2, _ => 3 }", 3},
+		{"match ([1, 2, 3]) { [] => 0, [a] => a, [a, b, c] => a + b + c }", 6},
+		{"match ([1, 2]) { [a, b, c] => 1, [a, b] => 2 }", 2},
+		{"match ([1, 2, 3]) { [first, ...rest] => first * 10 + len(rest) }", 12},
+		{"match ([1]) { [first, ...rest] => len(rest) }", 0},
+		{"match ([]) { [first, ...rest] => 1, _ => 2 }", 2},
+		{"match ([1, 2, 3]) { [..._] => 7 }", 7},
+		{"match ([1, 2, 3]) { [first, ...] => first }", 1},
+		{"match ([1]) { [_, _, ...] => 0, [...] => 7 }", 7},
+		{"let [a, ...] = [4, 5, 6]; a", 4},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b * c }", 7},
+		{"match ([1, [2]]) { [a, [b, c]] => 1, [a, [b]] => 2 }", 2},
+		{"match ([1, 2]) { [2, x] => x, [1, x] => x * 10 }", 20},
+		{`match ({"type": "add", "x": 3, "y": 4}) { {"type": "sub", "x": x, "y": y} => x - y, {"type": "add", "x": x, "y": y} => x + y }`, 7},
+		{`match ({"x": 1}) { {"x": 1, "y": y} => y, {"x": x} => x + 100 }`, 101},
+		{`match ({"p": [1, 2]}) { {"p": [a, b]} => a + b }`, 3},
+		{`match ({"p": [1, 2]}) { {"p": [a]} => a, {"p": [a, b, ...r]} => len(r) }`, 0},
+		{"match (1) { [a] => a, {} => 2, _ => 3 }", 3},
+		{`match ({"x": 1, "y": 2}) { {x: 2} => 0, {x, y} => x + y }`, 3},
+		{"match (1) { x => { let y = x + 1; y * 2 } }", 4},
+		{"match ([1, 2]) { [a, b] if a > b => 1, [a, b] => a - b }", -1},
+		{"let f = fn(xs) { match (xs) { [] => 0, [x, ...rest] => x + f(rest) } }; f([1, 2, 3, 4])", 10},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { match (x) { 3 => break, n => s += n } }; s", 3},
+		{"let g = fn(v) { match (v) { [a, [b, 9]] => a + b, _ => 0 } }; g([1, [2, 9]]) + g([1, [2, 3]]) + g([1, 2])", 3},
+		{"let x = 10; match (5) { x if x > 100 => 0, _ => 1 }; x", 10},
+		{"let x = 10; let y = match (5) { x => x * 2 }; y + x", 20},
+		{"let f = fn() { let x = 10; match (5) { x if x > 100 => 0, _ => 1 }; x }; f()", 10},
+		{"let x = 10; match ([1, 2]) { [x, 3] => 0, _ => x }", 10},
+		{"const x = 1; match (2) { x => x }", 2},
+		{"const x = 1; match (2) { x => x }; x", 1},
+		{"let g = match (3) { n => fn() { n } }; let n = 7; g()", 3},
+		{"match ([1, 2]) { [a, 3] => 0, _ => a }", "identifier not found: a at line 1, column 36"},
+		{"match (1) { n => n }; n", "identifier not found: n at line 1, column 23"},
+		{"match (1 / 0) { _ => 1 }", "division by zero: 1 / 0 at line 1, column 10"},
+		{"match (1) { n if n / 0 => 1 }", "division by zero: 1 / 0 at line 1, column 20"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLetDestructuring(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a * 10 + b", 12},
+		{"let [a, b, c] = [1, 2]; c", nil},
+		{"let [a, ...rest] = [1, 2, 3]; a + len(rest)", 3},
+		{"let [a, b, ...rest] = [1]; len(rest)", 0},
+		{"let [_, b] = [1, 2]; b", 2},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b * c", 7},
+		{"let {name, age: years} = {\"name\": 1, \"age\": 2}; name * 10 + years", 12},
+		{"let {\"x\": x, missing} = {\"x\": 4}; x", 4},
+		{"let {missing} = {}; missing", nil},
+		{"let {p: [a, b]} = {\"p\": [5, 6]}; a + b", 11},
+		{"let f = fn(pair) { let [x, y] = pair; x - y }; f([5, 2])", 3},
+		{"const [a, b] = [1, 2]; a + b", 3},
+		{"let [a, b] = 1;", "cannot destructure INTEGER as ARRAY at line 1, column 5"},
+		{"let {a} = [1];", "cannot destructure ARRAY as HASH at line 1, column 5"},
+		{"let [a, [b]] = [1, 2];", "cannot destructure INTEGER as ARRAY at line 1, column 9"},
+		{"const a = 1; let [a] = [2];", "cannot redeclare constant a at line 1, column 19"},
+		{"const [a] = [1]; a = 2", "cannot assign to constant a at line 1, column 20"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestWhileExpressions(t *testing.T) {
 	tests := []struct{
 		input string
@@ -161,6 +483,303 @@ func TestWhileExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"let s = 0; for (x in [1, 2, 3]) { s += x }; s", 6},
+		{"let s = 0; for (i, x in [10, 20]) { s += i * x }; s", 20},
+		{`let s = ""; for (c in "héllo") { s = c + s }; s`, "olléh"},
+		{`let s = ""; for (k in {"b": 1, "a": 2}) { s += k }; s`, "ab"},
+		{`let s = 0; for (k, v in {"b": 1, "a": 2}) { s = s * 10 + v }; s`, 21},
+		{"for (x in [1]) { x }", nil},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } s += x }; s", 3},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x % 2 == 0) { continue; } s += x }; s", 4},
+		{"let s = 0; for (x in [1, 2]) { for (y in [1, 2, 3]) { if (y == 2) { break; } s += x * y } }; s", 3},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } }; i", 5},
+		{"let i = 0; let s = 0; while (i < 5) { i += 1; if (i == 2) { continue; } s += i }; s", 13},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } }", nil},
+		{"let f = fn(a) { for (x in a) { if (x > 1) { return x } }; 0 }; f([1, 5, 7])", 5},
+		{"let fs = []; for (x in [1, 2]) { let f = fn() { x }; if (x == 1) { fs = [f] } }; fs[0]()", 1},
+		{"let x = 100; for (x in [1, 2]) {}; x", 100},
+		{"let fs = []; for (i in [1, 2, 3]) { fs = push(fs, fn() { i }) }; fs[0]() * 10 + fs[1]()", 12},
+		{"let fs = []; for (i in [1, 2, 3]) { let j = i * 2; fs = push(fs, fn() { j }) }; fs[0]() * 10 + fs[2]()", 26},
+		{"let fs = []; for (i in [1, 2]) { fs = push(fs, fn() { i += 10; i }) }; fs[0](); fs[0]() * 100 + fs[1]()", 2112},
+		{"let f = fn() { let fs = []; for (i in [1, 2, 3]) { fs = push(fs, fn() { i }) }; fs[0]() * 10 + fs[1]() }; f()", 12},
+		{"let f = fn() { let x = 100; for (x in [1, 2]) {}; x }; f()", 100},
+		{"let s = 0; for (x in [1, 2]) { let y = x; s += y }; s", 3},
+		{"const x = 1; let s = 0; for (x in [5]) { s = x }; s + x", 6},
+		{"let s = 0; for (x in [1, 2, 3]) { s += [x, if (x == 2) { continue } else { 0 }][0] }; s", 4},
+		{"let s = []; for (x in [1, 2, 3]) { s = push(s, [x, switch (x) { case 2: continue default: x }]) }; len(s) * 10 + s[1][1]", 23},
+		{"let s = 0; for (x in [1, 2, 3]) { s += 1 + if (x == 2) { break } else { x } }; s", 2},
+		{"let s = 0; for (x in 1..=4) { s += len([x, if (x > 2) { break } else { x }]) }; s", 4},
+		{"let i = 0; let s = 0; while (i < 5) { i += 1; s += {i: if (i == 3) { continue } else { i }}[i] }; s", 12},
+		{"let s = 0; for (x in [1, 2]) { for (y in [1, 2]) { s += x * if (y == 2) { break } else { y } } }; s", 3},
+		{"for (x in 5) { x }", "INTEGER is not iterable at line 1, column 1"},
+		{"for (x in [1, 0]) { 1 / x }", "division by zero: 1 / 0 at line 1, column 23"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, evaluated.Message)
+				}
+			case *object.String:
+				if evaluated.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, evaluated.Value)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"len(0..10)", 10},
+		{"len(0..=10)", 11},
+		{"len(0..10 step 3)", 4},
+		{"len(10..0 step -2)", 5},
+		{"len(5..1)", 0},
+		{"(0..10 step 3)[2]", 6},
+		{"(10..=0 step -5)[2]", 0},
+		{"(0..3)[3]", nil},
+		{"let s = 0; for (i in 1..=4) { s += i }; s", 10},
+		{"let s = 0; for (i, x in 10..0 step -5) { s += i * x }; s", 5},
+		{"len(0..1000000000000)", 1000000000000},
+		{"(0..=9223372036854775807)[-1]", 9223372036854775807},
+		{"let s = 0; for (i in 0..=9223372036854775807) { if (i == 3) { break } s += i }; s", 3},
+		{"if (9223372036854775806 in 0..=9223372036854775807) { 1 } else { 0 }", 1},
+		{"len(0..=9223372036854775807)", "range 0..=9223372036854775807 has more than 9223372036854775807 values at line 1, column 4"},
+		{"if (4 in 0..10 step 2) { 1 } else { 0 }", 1},
+		{"if (5 in 0..10 step 2) { 1 } else { 0 }", 0},
+		{"if (10 in 0..10) { 1 } else { 0 }", 0},
+		{"if (10 in 0..=10) { 1 } else { 0 }", 1},
+		{`if (2 in [1, 2, 3] && "b" in {"b": 1} && "ell" in "hello") { 1 } else { 0 }`, 1},
+		{"let a = [1, 2, 3, 4]; let b = a[1..3]; len(b) * 10 + b[0]", 22},
+		{"[1, 2, 3, 4][3..=0 step -3][1]", 1},
+		{`"hello"[1..4]`, "ell"},
+		{"1..3", "1..3"},
+		{"0..=10 step 5", "0..=10 step 5"},
+		{"1..true", "range bounds must be INTEGER, got BOOLEAN at line 1, column 2"},
+		{"0..10 step 0", "range step cannot be zero at line 1, column 2"},
+		{"[1, 2][1..3]", "index 2 out of range for array of length 2 at line 1, column 7"},
+		{"1 in 5", "in operator not supported: INTEGER at line 1, column 3"},
+		{`1 in "abc"`, "type mismatch: INTEGER in STRING at line 1, column 3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, evaluated.Message)
+				}
+			case *object.String:
+				if evaluated.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, evaluated.Value)
+				}
+			case *object.Range:
+				if evaluated.Inspect() != expected {
+					t.Errorf("Range has wrong value. expected=%q, got=%q", expected, evaluated.Inspect())
+				}
+			default:
+				t.Errorf("object is not String, Range or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{`"héllo"[1]`, "é"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[:-2]`, "hel"},
+		{`"hello"[3:]`, "lo"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"hello"[::2]`, "hlo"},
+		{`"hello"[-100:100]`, "hello"},
+		{`"hello"[4:1]`, ""},
+		{"let a = [1, 2, 3, 4, 5]; let b = a[1:4]; len(b) * 10 + b[0]", 32},
+		{"let a = [1, 2, 3, 4, 5]; a[-2:][0]", 4},
+		{"let a = [1, 2, 3, 4, 5]; a[:][4]", 5},
+		{"let a = [1, 2, 3, 4, 5]; let b = a[4:0:-2]; len(b) * 10 + b[1]", 23},
+		{"let a = [1, 2, 3]; let b = a[:]; b[0] = 9; a[0]", 1},
+		{"len([1, 2, 3][5:])", 0},
+		{"(0..10)[-1]", 9},
+		{"[1, 2][::0]", "slice step cannot be zero at line 1, column 7"},
+		{`[1, 2]["a":]`, "slice bounds must be INTEGER, got STRING at line 1, column 7"},
+		{"5[1:]", "slice not supported: INTEGER at line 1, column 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, evaluated.Message)
+				}
+			case *object.String:
+				if evaluated.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, evaluated.Value)
+				}
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = 2", 2},
+		{"let x = 1; let y = 1; x = y = 5; x + y", 10},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let x = 1; let f = fn() { x += 1 }; f(); f(); x", 3},
+		{"let x = 1; let f = fn() { let x = 5; x = 6 }; f(); x", 1},
+		{"let f = fn(a) { a *= 3; a }; f(4)", 12},
+		{"let counter = fn() { let c = 0; fn() { c += 1 } }; let a = counter(); a(); a()", 2},
+		{"let x = 0; let i = 0; while (i < 5) { i += 1; x += i }; x", 15},
+		{"let f = fn() { let i = 0; while (true) { i += 1; if (i == 3) { return i } } }; f()", 3},
+		{"x = 1", "cannot assign to undefined variable x at line 1, column 3"},
+		{"len += 1", "cannot assign to undefined variable len at line 1, column 5"},
+		{`let s = "a"; s -= 1`, "type mismatch: STRING - INTEGER"},
+		{"let i = 0; while (true) { i += true }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, evaluated.Message)
+				}
+			case *object.String:
+				if evaluated.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, evaluated.Value)
+				}
+			default:
+				t.Errorf("unexpected object %T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestIndexAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[1] = 5; a[1]", 5},
+		{"let a = [1, 2, 3]; a[0] = a[2] = 7; a[0] + a[2]", 14},
+		{"let a = [1, 2, 3]; let b = a; b[2] = 10; a[2]", 10},
+		{"let a = [0]; let f = fn(arr) { arr[0] = 9 }; f(a); a[0]", 9},
+		{`let h = {"a": 1}; h["a"] = 2; h["b"] = 3; h["a"] + h["b"]`, 5},
+		{`let h = {}; h[true] = 1; h[1] = 2; h[true] + h[1]`, 3},
+		{"let a = [1]; a[0] = 4", 4},
+		{"let a = [1, 2, 3]; a[1] += 5; a[1]", 7},
+		{`let h = {"n": 10}; h["n"] -= 4; h["n"] *= 3; h["n"] /= 2`, 9},
+		{"let m = [[1]]; m[0][0] += 1; m[0][0]", 2},
+		{"let a = [1, 2]; let i = 0; let next = fn() { i += 1; i - 1 }; a[next()] += 10; a[0] * 10 + i", 111},
+		{"let a = [1]; a[0] /= 0", "division by zero: 1 / 0 at line 1, column 19"},
+		{"let a = [1, 2]; a[2] = 1", "index 2 out of range for array of length 2 at line 1, column 22"},
+		{"let a = [1, 2]; a[-1] = 5; a[1]", 5},
+		{"let a = [1, 2]; a[-3] = 1", "index -3 out of range for array of length 2 at line 1, column 23"},
+		{`let a = [1]; a["0"] = 1`, "array index must be INTEGER, got STRING at line 1, column 21"},
+		{"let h = {}; h[[]] = 1", "unusable as hash key: ARRAY at line 1, column 19"},
+		{"let s = 1; s[0] = 1", "index assignment not supported: INTEGER at line 1, column 17"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"const a = 5; a", 5},
+		{"const a = [1]; a[0] = 2; a[0]", 2},
+		{"let a = 1; const a = 2; a", 2},
+		{"const a = 1; let f = fn() { let a = 2; a = 3 }; f() + a", 4},
+		{"const a = 1; let f = fn(a) { a += 1 }; f(5)", 6},
+		{"const a = 1; a = 2", "cannot assign to constant a at line 1, column 16"},
+		{"const a = 1; a += 2", "cannot assign to constant a at line 1, column 16"},
+		{"const a = 1; let a = 2", "cannot redeclare constant a at line 1, column 18"},
+		{"const a = 1; const a = 2", "cannot redeclare constant a at line 1, column 20"},
+		{"const a = 1; let f = fn() { a = 2 }; f()", "cannot assign to constant a at line 1, column 31\n  in f, called at line 1, column 39"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct{
 		input string
@@ -225,11 +844,25 @@ func TestErrorHandling(t *testing.T) {
 		  }
 			`, 
 			"unknown operator: BOOLEAN + BOOLEAN"},
-			{"foobar", "identifier not found: foobar"},
+			{"foobar", "identifier not found: foobar at line 1, column 1"},
 			{`"foo" - "bar"`, "unknown operator: STRING - STRING"},
-			{`5[0]`, "index operator not supported: INTEGER"},
-			{`{"name": "Goblin"}[fn(x) {x}];`, "unusable as hash key: FUNCTION"},
+			{`5[0]`, "index operator not supported: INTEGER at line 1, column 2"},
+			{`{"name": "Goblin"}[fn(x) {x}];`, "unusable as hash key: FUNCTION at line 1, column 19"},
+			{"len(1, 2)", "wrong number of arguments. got=2, want=1 at line 1, column 4"},
+			{`[1, 2]["a"]`, "index operator not supported: ARRAY at line 1, column 7"},
+			{"{}[fn() {}]", "unusable as hash key: FUNCTION at line 1, column 3"},
+			{"{fn() {}: 1}", "unusable as hash key: FUNCTION at line 1, column 1"},
+			{"let x = 1; x()", "not a function: INTEGER at line 1, column 13"},
 			{`"five" ** 5`, "type mismatch: STRING ** INTEGER"},
+			{"1 / 0", "division by zero: 1 / 0 at line 1, column 3"},
+			{"2 ** 100000000", "exponent too large: 2 ** 100000000 at line 1, column 3"},
+			{"let x = 0;\n10 / x + 1", "division by zero: 10 / 0 at line 2, column 4"},
+			{"(2 ** 64) / (1 - 1)", "division by zero: 18446744073709551616 / 0 at line 1, column 11"},
+			{"5 % 0", "division by zero: 5 % 0 at line 1, column 3"},
+			{"1 << -1", "negative shift amount: 1 << -1 at line 1, column 3"},
+			{"(2 ** 64) >> -2", "negative shift amount: 18446744073709551616 >> -2 at line 1, column 11"},
+			{"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+			{"~1.5", "unknown operator: ~FLOAT"},
 	}
 
 	for _, tt := range tests {
@@ -284,6 +917,79 @@ func TestFunctionObject(t *testing.T) {
 	if fn.Body.String() != expectedBody {
 		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
 	}
+
+	evaluated = testEval("fn(x, y = 1, ...rest) { x }")
+	expected := "fn(x, y = 1, ...rest)"
+	if evaluated.Inspect() != expected {
+		t.Fatalf("Inspect() is not %q. got=%q", expected, evaluated.Inspect())
+	}
+}
+
+func TestNamedFunctions(t *testing.T) {
+	evaluated := testEval("fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)")
+	testIntegerObject(t, evaluated, 120)
+
+	evaluated = testEval("fn outer() { fn a() { b() }; fn b() { 2 }; a() }; outer()")
+	testIntegerObject(t, evaluated, 2)
+
+	evaluated = testEval("fn even(n) { if (n == 0) { true } else { odd(n - 1) } }; fn odd(n) { if (n == 0) { false } else { even(n - 1) } }; even(10)")
+	testBooleanobject(t, evaluated, true)
+
+	inspects := []struct {
+		input string
+		expected string
+	}{
+		{"fn add(a, b = 1) { a + b }; add", "fn add(a, b = 1)"},
+		{"let twice = |x| x * 2; twice", "fn twice(x)"},
+		{"let f = fn() { 1 }; let g = f; g", "fn f()"},
+		{"|x, y| x", "fn(x, y)"},
+	}
+
+	for _, tt := range inspects {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("Inspect() is not %q. got=%q", tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errors := []struct {
+		input string
+		expected string
+	}{
+		{
+			"fn inner() { 1 / 0 }\nfn outer() { inner() }\nouter()",
+			"division by zero: 1 / 0 at line 1, column 16\n  in inner, called at line 2, column 19\n  in outer, called at line 3, column 6",
+		},
+		{
+			"let f = fn(g) { g() }; f(|| 1 / 0)",
+			"division by zero: 1 / 0 at line 1, column 31\n  in anonymous function, called at line 1, column 18\n  in f, called at line 1, column 25",
+		},
+		{
+			"fn a() { b() }; fn b() { 1 / 0 }; a()",
+			"division by zero: 1 / 0 at line 1, column 28\n  in b, called at line 1, column 11\n  in a, called at line 1, column 36",
+		},
+		{"a(); fn a() { 1 }", "identifier not found: a at line 1, column 1"},
+		{"fn f() { g() }; f(); fn g() { 1 }", "identifier not found: g at line 1, column 10\n  in f, called at line 1, column 18"},
+		{"fn f() { g(); fn g() { 1 } }; f()", "identifier not found: g at line 1, column 10\n  in f, called at line 1, column 32"},
+		{"fn f() { let h = || g(); h(); fn g() { 1 } }; f()", "identifier not found: g at line 1, column 21\n  in h, called at line 1, column 27\n  in f, called at line 1, column 48"},
+		{
+			"fn down(n) { if (n == 0) { 1 / 0 } else { down(n - 1) } }; down(20)",
+			"division by zero: 1 / 0 at line 1, column 30" + strings.Repeat("\n  in down, called at line 1, column 47", object.MaxTraceCalls) + "\n  ...",
+		},
+	}
+
+	for _, tt := range errors {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
 }
 
 func TestFunctionApplication(t *testing.T) {
@@ -304,6 +1010,141 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input string
+		expected interface{}
+	}{
+		{"let f = fn(x, y = 10) { x + y }; f(1)", 11},
+		{"let f = fn(x, y = 10) { x + y }; f(1, 2)", 3},
+		{"let f = fn(x, y = x * 2) { x + y }; f(3)", 9},
+		{"let f = fn(first, ...others) { first + len(others) }; f(1, 2, 3)", 3},
+		{"let f = fn(...xs) { len(xs) }; f()", 0},
+		{"let f = fn(a, b = 2, ...r) { a * 100 + b * 10 + len(r) }; f(1)", 120},
+		{"let f = fn(a, b = 2, ...r) { a * 100 + b * 10 + len(r) }; f(1, 3, 4, 5)", 132},
+		{"let f = fn(...xs) { xs[1] }; f(1, 2, 3)", 2},
+		{"let f = fn(x, y) { x - y }; f(y: 1, x: 5)", 4},
+		{"let f = fn(x, y = 1, z = 2) { x + y * 10 + z * 100 }; f(0, z: 5)", 510},
+		{"let y = 100; let f = fn(x = y, y = 1) { x + y }; f()", 101},
+		{"let f = fn(x, g = fn() { x * 2 }) { g() }; f(4)", 8},
+		{"let f = fn(n, acc = 1) { if (n == 0) { acc } else { f(n - 1, acc: acc * n) } }; f(5)", 120},
+		{"fn(a) { a }(1, 2)", "wrong number of arguments: want=1, got=2 at line 1, column 12"},
+		{"fn(a, b = 1) { a }()", "wrong number of arguments: want=1 to 2, got=0 at line 1, column 19"},
+		{"fn(a, ...r) { a }()", "wrong number of arguments: want=at least 1, got=0 at line 1, column 18"},
+		{"fn(a) { a }(b: 1)", "unknown parameter b at line 1, column 12"},
+		{"fn(a) { a }(1, a: 2)", "parameter a given more than once at line 1, column 12"},
+		{"fn(a, b = 2) { a }(b: 1)", "missing argument for parameter a at line 1, column 19"},
+		{"len(s: \"\")", "builtin functions do not take named arguments at line 1, column 4"},
+		{"fn(a = 1 / 0) { a }()", "division by zero: 1 / 0 at line 1, column 10\n  in anonymous function, called at line 1, column 20"},
+		{"fn(a) { a }()", "wrong number of arguments: want=1, got=0 at line 1, column 12"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestSpreadExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{"let a = [1, 2]; let b = [4]; [...a, 3, ...b]", "[1, 2, 3, 4]"},
+		{"[...[], ...[]]", "[]"},
+		{"[0, ...1..=3]", "[0, 1, 2, 3]"},
+		{"let a = [1]; let b = [...a]; b[0] = 5; a", "[1]"},
+		{"let d = {\"x\": 1, \"y\": 2}; let h = {...d, \"y\": 3}; [h[\"x\"], h[\"y\"]]", "[1, 3]"},
+		{"let h = {\"y\": 3, ...{\"y\": 1}}; h[\"y\"]", "3"},
+		{"let h = {...{\"a\": 1}, ...{\"a\": 2, \"b\": 3}}; [h[\"a\"], h[\"b\"]]", "[2, 3]"},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(...[1, 2, 3])", "123"},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(1, ...[2], 3)", "123"},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(...[1, 2], c: 3)", "123"},
+		{"let f = fn(a, b = 5, ...r) { [a, b, len(r)] }; f(...[1], ...[2, 3, 4])", "[1, 2, 2]"},
+		{"len(...[\"abc\"])", "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	errorTests := []struct {
+		input string
+		expectedMessage string
+	}{
+		{"[...1]", "spread operator not supported: INTEGER at line 1, column 2"},
+		{"{...[1]}", "spread operator not supported in hash: ARRAY at line 1, column 2"},
+		{"fn(a) { a }(...5)", "spread operator not supported: INTEGER at line 1, column 13"},
+		{"fn(a) { a }(...[1, 2])", "wrong number of arguments: want=1, got=2 at line 1, column 12"},
+	}
+
+	for _, tt := range errorTests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestLambdas(t *testing.T) {
+	tests := []struct {
+		input string
+		expected int64
+	}{
+		{"let double = |x| x * 2; double(21)", 42},
+		{"let f = || 42; f()", 42},
+		{"let add = |a, b = 10| a + b; add(1)", 11},
+		{"let count = |...xs| len(xs); count(1, 2, 3)", 3},
+		{"let f = |x| { let y = x * 2; y + 1 }; f(2)", 5},
+		{"let apply = fn(f, x) { f(x) }; apply(|n| n + 1, 1)", 2},
+		{"let k = 3; let f = |x| x * k; f(2)", 6},
+		{"let adder = |x| |y| x + y; adder(1)(2)", 3},
+		{"let s = 0; for (x in [1, 2, 3]) { let f = |n| n * 10; s += f(x) }; s", 60},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expected int64
+	}{
+		{"[1, 2, 3] |> len()", 3},
+		{"let double = |x| x * 2; 5 |> double", 10},
+		{"let add = fn(a, b) { a + b }; 1 |> add(2) |> add(3)", 6},
+		{"let map = fn(xs, f) { let out = []; for (x in xs) { out = push(out, f(x)) }; out }; [1, 2, 3] |> map(|x| x * 10) |> rest() |> first()", 20},
+		{"let x = 0; x = 3 |> |n| n * n; x", 9},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(b: 4)", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 	let newAdder = fn(x) {
@@ -325,29 +1166,29 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `len` not supported. got INTEGER"},
-		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		{`len(1)`, "argument to `len` not supported. got INTEGER at line 1, column 4"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, want=1 at line 1, column 4"},
 		{`len([1, 2])`, 2},
 		{`first([])`, nil},
 		{`first([1, 2])`, 1},
-		{`first("string")`, "argument to `first` not supported. got STRING"},
-		{`first([1, 2], [3, 4])`, "wrong number of arguments. got=2, want=1"},
+		{`first("string")`, "argument to `first` not supported. got STRING at line 1, column 6"},
+		{`first([1, 2], [3, 4])`, "wrong number of arguments. got=2, want=1 at line 1, column 6"},
 		{`last([])`, nil},
 		{`last([1])`, 1},
 		{`last([1, 2])`, 2},
-		{`last("string")`, "argument to `last` not supported. got STRING"},
-		{`last([1, 2], [3, 4])`, "wrong number of arguments. got=2, want=1"},
+		{`last("string")`, "argument to `last` not supported. got STRING at line 1, column 5"},
+		{`last([1, 2], [3, 4])`, "wrong number of arguments. got=2, want=1 at line 1, column 5"},
 		{`rest([1, 2])`, []int{2}},
 		{`rest([2])`, []int{}},
 		{`rest([])`, nil},
-		{`rest("string")`, "argument to `rest` not supported. got STRING"},
-		{`rest([1, 2], [3, 4])`, "wrong number of arguments. got=2, want=1"},
+		{`rest("string")`, "argument to `rest` not supported. got STRING at line 1, column 5"},
+		{`rest([1, 2], [3, 4])`, "wrong number of arguments. got=2, want=1 at line 1, column 5"},
 		{`push([], 1)`, []int{1}},
 		{`push([1, 2], 3)`, []int{1, 2, 3}},
 		{`push([1, 2], "three")`, []interface{}{1, 2, "three"}},
 		{`push([1, 2], [3, 4])`, []interface{}{1, 2, []int{3, 4}}},
-		{`push([1, 2])`, "wrong number of arguments. got=1, want=2"},
-		{`push(5, 10)`, "argument to `push` not supported. got INTEGER"},
+		{`push([1, 2])`, "wrong number of arguments. got=1, want=2 at line 1, column 5"},
+		{`push(5, 10)`, "argument to `push` not supported. got INTEGER at line 1, column 5"},
 	}
 
 	for _, tt := range tests {
@@ -421,7 +1262,9 @@ func TestArrayIndexExpressions(t *testing.T) {
 		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
 		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
 		{"[1, 2, 3][3]", nil},
-		{"[1, 2, 3][-1]", nil},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[1, 2, 3][-4]", nil},
 	}
 
 	for _, tt := range tests {
@@ -490,6 +1333,10 @@ func TestHashIndexExpressions(t *testing.T) {
 		{`{5: 5}[5]`, 5},
 		{`{true: 5}[true]`, 5},
 		{`{false: 5}[false]`, 5},
+		{`{2: 5}[2.0]`, 5},
+		{`{2.0: 5}[2]`, 5},
+		{`{2.5: 5}[2.5]`, 5},
+		{`{2.5: 5}[2]`, nil},
 	}
 
 	for _, tt := range tests {
@@ -531,6 +1378,21 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g. expected=%g", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
 func testStringObject(t *testing.T, obj object.Object, expected string) bool {
 	result, ok := obj.(*object.String)
 	if !ok {
@@ -26,7 +26,7 @@ func DefineMacros(program *ast.Program, env *object.Environment) {
 
 func isMacroDefinition(node ast.Statement) bool {
 	letStatement, ok := node.(*ast.LetStatement)
-	if !ok {
+	if !ok || letStatement.Name == nil {
 		return false
 	}
 
@@ -51,29 +51,35 @@ func addMacro(stmt ast.Statement, env *object.Environment) {
 	env.Set(letStatement.Name.Value, macro)
 }
 
+// ExpandMacros expands the outermost macro calls first, handing each macro
+// its arguments unexpanded, and then the macro calls in what they expand to.
 func ExpandMacros(program ast.Node, env *object.Environment) ast.Node {
-	return ast.Modify(program, func(node ast.Node) ast.Node {
-		callExpression, ok := node.(*ast.CallExpression)
-		if !ok {
-			return node
-		}
-
-		macro, ok := isMacroCall(callExpression, env)
-		if !ok {
-			return node
-		}
-
-		args := quoteArgs(callExpression)
-		evalEnv := extendMacroEnv(macro, args)
-
-		evaluated := Eval(macro.Body, evalEnv)
-
-		quote, ok := evaluated.(*object.Quote)
-		if !ok {
-			panic("we only support returning AST-nodes from macros")
+	return ast.ModifyOutsideIn(program, func(node ast.Node) ast.Node {
+		// An expansion can be a macro call itself, which is expanded
+		// before anything inside of it.
+		for {
+			callExpression, ok := node.(*ast.CallExpression)
+			if !ok {
+				return node
+			}
+
+			macro, ok := isMacroCall(callExpression, env)
+			if !ok {
+				return node
+			}
+
+			args := quoteArgs(callExpression)
+			evalEnv := extendMacroEnv(macro, args)
+
+			evaluated := Eval(macro.Body, evalEnv)
+
+			quote, ok := evaluated.(*object.Quote)
+			if !ok {
+				panic("we only support returning AST-nodes from macros")
+			}
+
+			node = quote.Node
 		}
-
-		return quote.Node
 	})
 }
 
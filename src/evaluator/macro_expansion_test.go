@@ -103,6 +103,47 @@ func TestExpandMacros(t *testing.T) {
 			`
 			if (!(10 > 5)) { puts("not greater") } else { puts("greater") }
 			`},
+		{`
+		let twice = macro(f) { quote(|x| unquote(f)(unquote(f)(x))); };
+		twice(|y| y + 1);
+		`,
+		`
+		fn(x) { fn(y) { y + 1 }(fn(y) { y + 1 }(x)) }
+		`},
+		// size measures its argument as written, before one() is expanded.
+		{`
+		let one = macro() { quote(1); };
+		let size = macro(x) { quote(unquote(len("${x}"))); };
+		size(one());
+		`,
+		`
+		12
+		`},
+		{`
+		let one = macro() { quote(1); };
+		let double = macro(x) { quote(unquote(x) + unquote(x)); };
+		double(one());
+		`,
+		`
+		1 + 1
+		`},
+		{`
+		let one = macro() { quote(1); };
+		let wrap = macro() { quote(one()); };
+		wrap() + 1;
+		`,
+		`
+		1 + 1
+		`},
+		{`
+		let unless = macro(condition, consequence) {
+			quote(if (!(unquote(condition))) { unquote(consequence); });
+		};
+		while (x) { unless(y, z); }
+		`,
+		`
+		while (x) { if (!(y)) { z } }
+		`},
 	}
 
 	for _, tt := range tests {
@@ -3,6 +3,9 @@ package lexer
 import (
 	"fmt"
 	"goblin/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 
@@ -11,15 +14,37 @@ type Lexer struct {
 	position int
 	readPosition int
 	ch byte
+	line int
+	column int
+	errors []string
+	interpolations []interpolation
+}
+
+// interpolation is an open ${...} in a string, with the braces opened inside
+// it that still need closing and the position of its ${.
+type interpolation struct {
+	braces int
+	line int
+	column int
 }
 
 func New(input string) *Lexer {
-	l:= &Lexer{input: input}
+	l:= &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
 
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -31,30 +56,75 @@ func (l *Lexer) readChar() {
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	l.skipWhitespace()
+	line, column := l.line, l.column
+
+	tok := l.readToken()
+	tok.Line = line
+	tok.Column = column
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
 			tok = l.newTwoCharToken(token.EQ)
+		} else if l.peekChar() == '>' {
+			tok = l.newTwoCharToken(token.ARROW)
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.PLUS_ASSIGN)
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.MINUS_ASSIGN)
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '*':
 		if l.peekChar() =='*' {
 			tok = l.newTwoCharToken(token.EXPONENT)
+		} else if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.ASTERISK_ASSIGN)
 		} else {
 
 			tok = newToken(token.ASTERISK, l.ch)
 		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newTwoCharToken(token.SLASH_ASSIGN)
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
+	case '%':
+		tok = newToken(token.MODULO, l.ch)
+	case '&':
+		if l.peekChar() == '&' {
+			tok = l.newTwoCharToken(token.AND)
+		} else {
+			tok = newToken(token.BIT_AND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			tok = l.newTwoCharToken(token.OR)
+		} else if l.peekChar() == '>' {
+			tok = l.newTwoCharToken(token.PIPE)
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
+	case '~':
+		tok = newToken(token.BIT_NOT, l.ch)
 	case '!':
 		if l.peekChar() == '=' {
 			tok = l.newTwoCharToken(token.NOT_EQ)
@@ -64,12 +134,16 @@ func (l *Lexer) NextToken() token.Token {
 	case '<':
 		if l.peekChar() == '=' {
 			tok = l.newTwoCharToken(token.LT_EQ)
+		} else if l.peekChar() == '<' {
+			tok = l.newTwoCharToken(token.SHIFT_LEFT)
 		} else {
 			tok = newToken(token.LT, l.ch)
 		}
 	case '>':
 		if l.peekChar() == '=' {
 			tok = l.newTwoCharToken(token.GT_EQ)
+		} else if l.peekChar() == '>' {
+			tok = l.newTwoCharToken(token.SHIFT_RIGHT)
 		} else {
 			tok = newToken(token.GT, l.ch)
 		}
@@ -78,9 +152,21 @@ func (l *Lexer) NextToken() token.Token {
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '{':
+		if depth := len(l.interpolations); depth > 0 {
+			l.interpolations[depth - 1].braces += 1
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
-		tok = newToken(token.RBRACE, l.ch)
+		depth := len(l.interpolations)
+		if depth > 0 && l.interpolations[depth - 1].braces == 0 {
+			l.interpolations = l.interpolations[:depth - 1]
+			tok = l.readStringToken()
+		} else {
+			if depth > 0 {
+				l.interpolations[depth - 1].braces -= 1
+			}
+			tok = newToken(token.RBRACE, l.ch)
+		}
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
@@ -91,10 +177,39 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RBRACKET, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if l.peekChar() == '.' {
+			tok = l.newTwoCharToken(token.RANGE)
+			if l.peekChar() == '=' {
+				l.readChar()
+				tok.Type = token.RANGE_INCLUSIVE
+				tok.Literal += "="
+			} else if l.peekChar() == '.' {
+				l.readChar()
+				tok.Type = token.ELLIPSIS
+				tok.Literal += "."
+			}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '"':
+		if l.peekChar() == '"' && l.peekCharAt(2) == '"' {
+			tok.Type = token.STRING
+			tok.Literal = l.readTripleQuotedString()
+		} else {
+			tok = l.readStringToken()
+		}
+	case '`':
 		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok.Literal = l.readRawString()
 	case 0:
+		if len(l.interpolations) > 0 {
+			// Report the innermost ${ that was never closed, not the end of
+			// the input where the missing } was noticed.
+			open := l.interpolations[len(l.interpolations) - 1]
+			l.addError(open.line, open.column, "unterminated interpolation")
+			l.interpolations = nil
+		}
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
@@ -103,8 +218,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookUpIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -125,40 +239,251 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber consumes digits along with any letters or underscores so that
+// prefixes like 0x and separators like 1_000 reach the parser in one piece.
+// Decimal literals with a fraction or an exponent are read as floats.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
+	tokenType := token.TokenType(token.INT)
+	decimal := !(l.ch == '0' && strings.ContainsRune("xXoObB", rune(l.peekChar())))
 
-	for isDigit(l.ch) {
+	l.readDigits(decimal)
+
+	if decimal && l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
 		l.readChar()
+		l.readDigits(decimal)
+	}
+
+	literal := l.input[position:l.position]
+	if decimal && strings.ContainsAny(literal, "eE") {
+		tokenType = token.FLOAT
 	}
 	
-	return l.input[position:l.position]
+	return literal, tokenType
+}
+
+func (l *Lexer) readDigits(decimal bool) {
+	for isDigit(l.ch) || isLetter(l.ch) {
+		if decimal && (l.ch == 'e' || l.ch == 'E') && (l.peekChar() == '+' || l.peekChar() == '-') {
+			l.readChar()
+		}
+		l.readChar()
+	}
 }
 
-func (l *Lexer) readString() string {
-	str := ""
-
-	for { 
-		l.readChar() 
-		if  l.ch == '"' || l.ch == 0 {
-			break
-		} else if l.ch == '\\' {
-			switch {
-			case l.peekChar() ==  '"':
-				str += "\""
-			case l.peekChar() == 'n':
-				str += "\n"
-			case l.peekChar() == 't':
-				str += "\t"
+func (l *Lexer) readStringToken() token.Token {
+	literal, interpolated := l.readString()
+
+	if interpolated {
+		// readString stops on the { of the ${, so the $ is one column back.
+		l.interpolations = append(l.interpolations, interpolation{line: l.line, column: l.column - 1})
+		return token.Token{Type: token.INTERPOLATION, Literal: literal}
+	}
+
+	return token.Token{Type: token.STRING, Literal: literal}
+}
+
+// readString reads up to the closing quote or the start of an embedded
+// ${...} expression, reporting which of the two ended the segment.
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
+	line, column := l.line, l.column
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '"':
+			return out.String(), false
+		case 0:
+			l.addError(line, column, "unterminated string literal")
+			return out.String(), false
+		case '$':
+			if l.peekChar() == '{' {
+				if l.emptyInterpolation() {
+					// Skip the ${} so that the parser does not trip over
+					// the missing expression after it has been reported.
+					l.addError(l.line, l.column, "empty interpolation")
+					for l.ch != '}' {
+						l.readChar()
+					}
+					continue
+				}
+				l.readChar()
+				return out.String(), true
 			}
+			out.WriteByte(l.ch)
+		case '\\':
+			l.readEscape(&out)
+		default:
+			out.WriteByte(l.ch)
+		}
+	}
+}
+
+// emptyInterpolation reports whether the ${ at the current character is
+// closed again with nothing but whitespace in between.
+func (l *Lexer) emptyInterpolation() bool {
+	offset := 2
+	for l.peekCharAt(offset) == ' ' || l.peekCharAt(offset) == '\t' || l.peekCharAt(offset) == '\n' || l.peekCharAt(offset) == '\r' {
+		offset++
+	}
+
+	return l.peekCharAt(offset) == '}'
+}
+
+func (l *Lexer) readRawString() string {
+	line, column := l.line, l.column
+	position := l.position + 1
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '`':
+			return l.input[position:l.position]
+		case 0:
+			l.addError(line, column, "unterminated raw string literal")
+			return l.input[position:l.position]
+		}
+	}
+}
+
+func (l *Lexer) readTripleQuotedString() string {
+	line, column := l.line, l.column
+	l.readChar()
+	l.readChar()
+	position := l.position + 1
+
+	for {
+		l.readChar()
+
+		if l.ch == '"' && l.peekChar() == '"' && l.peekCharAt(2) == '"' {
+			text := l.input[position:l.position]
+			l.readChar()
 			l.readChar()
+			return dedent(text)
+		}
+
+		if l.ch == 0 {
+			l.addError(line, column, "unterminated triple-quoted string literal")
+			return dedent(l.input[position:l.position])
+		}
+	}
+}
+
+// dedent drops the line breaks directly after the opening and before the
+// closing quotes and removes the indentation shared by all non-blank lines.
+func dedent(text string) string {
+	lines := strings.Split(text, "\n")
+
+	if len(lines) > 1 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && strings.TrimLeft(lines[len(lines) - 1], " \t") == "" {
+		lines = lines[:len(lines) - 1]
+	}
+
+	indent := ""
+	first := true
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+
+		lineIndent := line[:len(line) - len(trimmed)]
+		if first {
+			indent = lineIndent
+			first = false
+			continue
+		}
+
+		for !strings.HasPrefix(lineIndent, indent) {
+			indent = indent[:len(indent) - 1]
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimLeft(line, " \t") == "" {
+			lines[i] = ""
 		} else {
-			str += fmt.Sprintf("%c", l.input[l.position])
+			lines[i] = strings.TrimPrefix(line, indent)
 		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (l *Lexer) readEscape(out *strings.Builder) {
+	line, column := l.line, l.column
 
+	if l.peekChar() == 0 {
+		return
 	}
+	l.readChar()
+
+	switch l.ch {
+	case '"':
+		out.WriteByte('"')
+	case '$':
+		out.WriteByte('$')
+	case '\\':
+		out.WriteByte('\\')
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '0':
+		out.WriteByte(0)
+	case 'x':
+		digits := l.readHexDigits(2)
+		if len(digits) != 2 {
+			l.addError(line, column, "invalid escape sequence \\x: expected 2 hex digits")
+			return
+		}
+		value, _ := strconv.ParseUint(digits, 16, 8)
+		out.WriteByte(byte(value))
+	case 'u':
+		if l.peekChar() != '{' {
+			l.addError(line, column, "invalid escape sequence \\u: expected '{'")
+			return
+		}
+		l.readChar()
+
+		digits := l.readHexDigits(6)
+		if len(digits) == 0 || l.peekChar() != '}' {
+			l.addError(line, column, "invalid escape sequence \\u: expected 1 to 6 hex digits followed by '}'")
+			return
+		}
+		l.readChar()
 
-	return str
+		value, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(value)) {
+			l.addError(line, column, fmt.Sprintf("invalid escape sequence \\u{%s}: not a valid unicode code point", digits))
+			return
+		}
+		out.WriteRune(rune(value))
+	default:
+		l.addError(line, column, fmt.Sprintf("unknown escape sequence \\%c", l.ch))
+	}
+}
+
+func (l *Lexer) readHexDigits(max int) string {
+	position := l.readPosition
+
+	for l.readPosition - position < max && isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+
+	return l.input[position:l.readPosition]
+}
+
+func (l *Lexer) addError(line int, column int, msg string) {
+	l.errors = append(l.errors, fmt.Sprintf("%s at line %d, column %d", msg, line, column))
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -168,10 +493,16 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+	return l.peekCharAt(1)
+}
+
+func (l *Lexer) peekCharAt(offset int) byte {
+	position := l.readPosition + offset - 1
+
+	if position >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		return l.input[position]
 	}
 }
 
@@ -193,4 +524,8 @@ func isLetter(ch byte) bool {
 
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
+}
+
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
\ No newline at end of file
@@ -210,4 +210,323 @@ func TestNextToken(t *testing.T) {
 	}
 
 
-}
\ No newline at end of file
+}
+func TestBitwiseOperators(t *testing.T) {
+	input := `a % b & c | d ^ ~e << 1 >> 2 <= >= && || += -= *= /= ** |>`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.MODULO, "%"},
+		{token.IDENT, "b"},
+		{token.BIT_AND, "&"},
+		{token.IDENT, "c"},
+		{token.BIT_OR, "|"},
+		{token.IDENT, "d"},
+		{token.BIT_XOR, "^"},
+		{token.BIT_NOT, "~"},
+		{token.IDENT, "e"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.INT, "1"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.INT, "2"},
+		{token.LT_EQ, "<="},
+		{token.GT_EQ, ">="},
+		{token.AND, "&&"},
+		{token.OR, "||"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.MINUS_ASSIGN, "-="},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.SLASH_ASSIGN, "/="},
+		{token.EXPONENT, "**"},
+		{token.PIPE, "|>"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestRangeOperators(t *testing.T) {
+	input := `1..10 0..=n 1.5..2 x in r [a, ...b] => ==`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "1"},
+		{token.RANGE, ".."},
+		{token.INT, "10"},
+		{token.INT, "0"},
+		{token.RANGE_INCLUSIVE, "..="},
+		{token.IDENT, "n"},
+		{token.FLOAT, "1.5"},
+		{token.RANGE, ".."},
+		{token.INT, "2"},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.IDENT, "r"},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "b"},
+		{token.RBRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.EQ, "=="},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{`"back\\slash"`, "back\\slash"},
+		{`"carriage\r"`, "carriage\r"},
+		{`"nul\0"`, "nul\x00"},
+		{`"\x41\x62"`, "Ab"},
+		{`"\u{48}\u{e9}"`, "Hé"},
+		{`"\u{1F600}"`, "\U0001F600"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.STRING {
+			t.Fatalf("tokentype wrong. expected=%q, got=%q", token.STRING, tok.Type)
+		}
+
+		if tok.Literal != tt.expected {
+			t.Errorf("literal wrong. expected=%q, got=%q", tt.expected, tok.Literal)
+		}
+
+		if len(l.Errors()) != 0 {
+			t.Errorf("unexpected lexer errors: %v", l.Errors())
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{`"unterminated`, "unterminated string literal at line 1, column 1"},
+		{"let x = 1;\n  \"abc", "unterminated string literal at line 2, column 3"},
+		{`"\q"`, "unknown escape sequence \\q at line 1, column 2"},
+		{`"\x4"`, "invalid escape sequence \\x: expected 2 hex digits at line 1, column 2"},
+		{`"\u41"`, "invalid escape sequence \\u: expected '{' at line 1, column 2"},
+		{`"\u{}"`, "invalid escape sequence \\u: expected 1 to 6 hex digits followed by '}' at line 1, column 2"},
+		{`"\u{D800}"`, "invalid escape sequence \\u{D800}: not a valid unicode code point at line 1, column 2"},
+		{"`raw\nstring", "unterminated raw string literal at line 1, column 1"},
+		{"\"\"\"\ntriple\n\"\"", "unterminated triple-quoted string literal at line 1, column 1"},
+		{`"abc ${}"`, "empty interpolation at line 1, column 6"},
+		{"\"${ x } and ${ \n }\"", "empty interpolation at line 1, column 13"},
+		{`"${`, "unterminated interpolation at line 1, column 2"},
+		{"let s = \"abc ${ x +\n  1", "unterminated interpolation at line 1, column 14"},
+		{`"a ${ "b ${ x`, "unterminated interpolation at line 1, column 10"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(errors), errors)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x + "two";`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine int
+		expectedColumn int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{"+", 2, 5},
+		{"two", 2, 7},
+		{";", 2, 12},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d", i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"hello ${name}, you have ${len({"a": 1})} items" "\${literal}"`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedLiteral string
+	}{
+		{token.INTERPOLATION, "hello "},
+		{token.IDENT, "name"},
+		{token.INTERPOLATION, ", you have "},
+		{token.IDENT, "len"},
+		{token.LPAREN, "("},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.RPAREN, ")"},
+		{token.STRING, " items"},
+		{token.STRING, "${literal}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected lexer errors: %v", l.Errors())
+	}
+}
+
+func TestRawAndTripleQuotedStrings(t *testing.T) {
+	input := "`raw \\n \"quoted\"\n  second line`;\n" +
+		"let sql = \"\"\"\n" +
+		"    SELECT *\n" +
+		"      FROM users\n" +
+		"\n" +
+		"    WHERE id = \"1\"\n" +
+		"    \"\"\";\n" +
+		"x"
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedLiteral string
+		expectedLine int
+	}{
+		{token.STRING, "raw \\n \"quoted\"\n  second line", 1},
+		{token.SEMICOLON, ";", 2},
+		{token.LET, "let", 3},
+		{token.IDENT, "sql", 3},
+		{token.ASSIGN, "=", 3},
+		{token.STRING, "SELECT *\n  FROM users\n\nWHERE id = \"1\"", 3},
+		{token.SEMICOLON, ";", 8},
+		{token.IDENT, "x", 9},
+		{token.EOF, "", 9},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine {
+			t.Errorf("tests[%d] - line wrong. expected=%d, got=%d", i, tt.expectedLine, tok.Line)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected lexer errors: %v", l.Errors())
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	input := `3.14 1_000.5 1e10 2.5e-3 0x1F 10 0b1010`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1_000.5"},
+		{token.FLOAT, "1e10"},
+		{token.FLOAT, "2.5e-3"},
+		{token.INT, "0x1F"},
+		{token.INT, "10"},
+		{token.INT, "0b1010"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package object
+
+import (
+	"fmt"
+	"goblin/ast"
+	"strconv"
+)
+
+// Arity describes the parameters of a function: Names in order, the first
+// Required of which have no default, and whether a rest parameter collects
+// the arguments left over.
+type Arity struct {
+	Names []string
+	Required int
+	HasRest bool
+}
+
+func NewArity(params []*ast.Identifier, defaults []ast.Expression, rest *ast.Identifier) *Arity {
+	arity := &Arity{HasRest: rest != nil}
+
+	for i, p := range params {
+		arity.Names = append(arity.Names, p.Value)
+		if i >= len(defaults) || defaults[i] == nil {
+			arity.Required = i + 1
+		}
+	}
+
+	return arity
+}
+
+func (a *Arity) String() string {
+	switch {
+	case a.HasRest:
+		return fmt.Sprintf("at least %d", a.Required)
+	case a.Required < len(a.Names):
+		return fmt.Sprintf("%d to %d", a.Required, len(a.Names))
+	default:
+		return strconv.Itoa(a.Required)
+	}
+}
+
+// Bind lines up the arguments of a call with the parameters. The last
+// len(names) arguments are passed by name, the ones before them by position.
+// The result holds the value of each parameter, nil for one that is left to
+// its default, followed by the rest array when there is a rest parameter.
+func (a *Arity) Bind(args []Object, names []string) ([]Object, error) {
+	positional := args[:len(args) - len(names)]
+
+	if len(names) == 0 && len(positional) == len(a.Names) && !a.HasRest {
+		return args, nil
+	}
+
+	if len(args) < a.Required || (len(positional) > len(a.Names) && !a.HasRest) {
+		return nil, fmt.Errorf("wrong number of arguments: want=%s, got=%d", a, len(args))
+	}
+
+	size := len(a.Names)
+	if a.HasRest {
+		size++
+	}
+	values := make([]Object, size)
+
+	n := copy(values[:len(a.Names)], positional)
+	if a.HasRest {
+		rest := []Object{}
+		if n < len(positional) {
+			rest = append(rest, positional[n:]...)
+		}
+		values[len(a.Names)] = &Array{Elements: rest}
+	}
+
+	for i, name := range names {
+		index := a.indexOf(name)
+		if index < 0 {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+		if values[index] != nil {
+			return nil, fmt.Errorf("parameter %s given more than once", name)
+		}
+		values[index] = args[len(positional) + i]
+	}
+
+	for i := 0; i < a.Required; i++ {
+		if values[i] == nil {
+			return nil, fmt.Errorf("missing argument for parameter %s", a.Names[i])
+		}
+	}
+
+	return values, nil
+}
+
+func (a *Arity) indexOf(name string) int {
+	for i, n := range a.Names {
+		if n == name {
+			return i
+		}
+	}
+
+	return -1
+}
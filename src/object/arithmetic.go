@@ -0,0 +1,264 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// maxBits bounds the size of the results of shifts and powers on big
+// integers so a typo cannot allocate gigabytes.
+const maxBits = 1 << 20
+
+// IntegerArithmetic is shared by the evaluator and the vm so that both
+// engines agree on exponentiation rules and on promoting results that
+// overflow int64 to a BigInt.
+func IntegerArithmetic(operator string, left, right int64) (Object, error) {
+	var result int64
+	var ok bool
+
+	switch operator {
+	case "+":
+		result, ok = addInt64(left, right)
+	case "-":
+		result, ok = subInt64(left, right)
+	case "*":
+		result, ok = mulInt64(left, right)
+	case "/":
+		if right == 0 {
+			return nil, divisionByZeroError(left, operator, right)
+		}
+		result, ok = left / right, !(left == math.MinInt64 && right == -1)
+	case "%":
+		if right == 0 {
+			return nil, divisionByZeroError(left, operator, right)
+		}
+		result, ok = left % right, true
+	case "&":
+		result, ok = left & right, true
+	case "|":
+		result, ok = left | right, true
+	case "^":
+		result, ok = left ^ right, true
+	case "<<":
+		if right < 0 {
+			return nil, negativeShiftError(left, operator, right)
+		}
+		result, ok = left << right, right < 64 && (left << right) >> right == left
+	case ">>":
+		if right < 0 {
+			return nil, negativeShiftError(left, operator, right)
+		}
+		result, ok = left >> right, true
+	case "**":
+		if right < 0 {
+			return &Float{Value: math.Pow(float64(left), float64(right))}, nil
+		}
+		result, ok = powInt64(left, right)
+	default:
+		return nil, fmt.Errorf("unknown operator: %s %s %s", INTEGER_OBJ, operator, INTEGER_OBJ)
+	}
+
+	if !ok {
+		return BigIntArithmetic(operator, big.NewInt(left), big.NewInt(right))
+	}
+
+	return &Integer{Value: result}, nil
+}
+
+func BigIntArithmetic(operator string, left, right *big.Int) (Object, error) {
+	result := new(big.Int)
+
+	switch operator {
+	case "+":
+		result.Add(left, right)
+	case "-":
+		result.Sub(left, right)
+	case "*":
+		result.Mul(left, right)
+	case "/":
+		if right.Sign() == 0 {
+			return nil, divisionByZeroError(left, operator, right)
+		}
+		result.Quo(left, right)
+	case "%":
+		if right.Sign() == 0 {
+			return nil, divisionByZeroError(left, operator, right)
+		}
+		result.Rem(left, right)
+	case "&":
+		result.And(left, right)
+	case "|":
+		result.Or(left, right)
+	case "^":
+		result.Xor(left, right)
+	case "<<", ">>":
+		if right.Sign() < 0 {
+			return nil, negativeShiftError(left, operator, right)
+		}
+		if !right.IsUint64() || right.Uint64() > maxBits {
+			return nil, fmt.Errorf("shift amount too large: %s %s %s", left, operator, right)
+		}
+		if operator == "<<" {
+			result.Lsh(left, uint(right.Uint64()))
+		} else {
+			result.Rsh(left, uint(right.Uint64()))
+		}
+	case "**":
+		if right.Sign() < 0 {
+			return &Float{Value: math.Pow(bigToFloat(left), bigToFloat(right))}, nil
+		}
+		// Powers of 0, 1 and -1 stay small however large the exponent is.
+		if left.BitLen() > 1 && (!right.IsUint64() || right.Uint64() > maxBits / uint64(left.BitLen() - 1)) {
+			return nil, fmt.Errorf("exponent too large: %s %s %s", left, operator, right)
+		}
+		result.Exp(left, right, nil)
+	default:
+		return nil, fmt.Errorf("unknown operator: %s %s %s", BIG_INTEGER_OBJ, operator, BIG_INTEGER_OBJ)
+	}
+
+	return NewInteger(result), nil
+}
+
+func divisionByZeroError(left interface{}, operator string, right interface{}) error {
+	return fmt.Errorf("division by zero: %v %s %v", left, operator, right)
+}
+
+func negativeShiftError(left interface{}, operator string, right interface{}) error {
+	return fmt.Errorf("negative shift amount: %v %s %v", left, operator, right)
+}
+
+func FloatArithmetic(operator string, left, right float64) (Object, error) {
+	switch operator {
+	case "+":
+		return &Float{Value: left + right}, nil
+	case "-":
+		return &Float{Value: left - right}, nil
+	case "*":
+		return &Float{Value: left * right}, nil
+	case "/":
+		return &Float{Value: left / right}, nil
+	case "**":
+		return &Float{Value: math.Pow(left, right)}, nil
+	case "%":
+		return &Float{Value: math.Mod(left, right)}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator: %s %s %s", FLOAT_OBJ, operator, FLOAT_OBJ)
+	}
+}
+
+func NegateInteger(obj Object) Object {
+	if integer, ok := obj.(*Integer); ok && integer.Value != math.MinInt64 {
+		return &Integer{Value: -integer.Value}
+	}
+
+	value, _ := ToBigInt(obj)
+	return NewInteger(new(big.Int).Neg(value))
+}
+
+func InvertInteger(obj Object) Object {
+	if integer, ok := obj.(*Integer); ok {
+		return &Integer{Value: ^integer.Value}
+	}
+
+	value, _ := ToBigInt(obj)
+	return NewInteger(new(big.Int).Not(value))
+}
+
+func CompareIntegers(left, right Object) int {
+	leftValue, _ := ToBigInt(left)
+	rightValue, _ := ToBigInt(right)
+	return leftValue.Cmp(rightValue)
+}
+
+// NewInteger demotes values that fit into an int64 back to an Integer.
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+
+	return &BigInt{Value: value}
+}
+
+func IsInteger(obj Object) bool {
+	return obj.Type() == INTEGER_OBJ || obj.Type() == BIG_INTEGER_OBJ
+}
+
+func IsNumber(obj Object) bool {
+	return IsInteger(obj) || obj.Type() == FLOAT_OBJ
+}
+
+func ToBigInt(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	case *BigInt:
+		return obj.Value, true
+	default:
+		return nil, false
+	}
+}
+
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *BigInt:
+		return bigToFloat(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	default:
+		return 0, false
+	}
+}
+
+func bigToFloat(value *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f
+}
+
+func addInt64(a, b int64) (int64, bool) {
+	result := a + b
+	return result, (result > a) == (b > 0)
+}
+
+func subInt64(a, b int64) (int64, bool) {
+	result := a - b
+	return result, (result < a) == (b > 0)
+}
+
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	result := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return result, false
+	}
+
+	return result, result / b == a
+}
+
+func powInt64(base, exponent int64) (int64, bool) {
+	result := int64(1)
+
+	for exponent > 0 {
+		if exponent & 1 == 1 {
+			var ok bool
+			if result, ok = mulInt64(result, base); !ok {
+				return result, false
+			}
+		}
+
+		exponent >>= 1
+		if exponent > 0 {
+			var ok bool
+			if base, ok = mulInt64(base, base); !ok {
+				return base, false
+			}
+		}
+	}
+
+	return result, true
+}
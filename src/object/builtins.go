@@ -0,0 +1,209 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Builtins is shared by the evaluator and the vm, which refers to a builtin
+// by its index in this slice. A builtin returns nil when its result is null.
+var Builtins = []struct {
+	Name string
+	Builtin *Builtin
+}{
+	{
+		"len",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *String:
+					return &Integer{Value: int64(len(arg.Value))}
+				case *Array:
+					return &Integer{Value: int64(len(arg.Elements))}
+				case *Range:
+					length, err := arg.Len()
+					if err != nil {
+						return newError("%s", err)
+					}
+					return &Integer{Value: length}
+				default:
+					return newError("argument to `len` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"first",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *Array:
+					if len(arg.Elements) > 0 {
+						return arg.Elements[0]
+					}
+					return nil
+				default:
+					return newError("argument to `first` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"last",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *Array:
+					length := len(arg.Elements)
+					if length > 0 {
+						return arg.Elements[length - 1]
+					}
+					return nil
+				default:
+					return newError("argument to `last` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"rest",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *Array:
+					length := len(arg.Elements)
+					if length > 0 {
+						newElements := make([]Object, length-1)
+						copy(newElements, arg.Elements[1:length])
+						return &Array{Elements: newElements}
+					}
+					return nil
+				default:
+					return newError("argument to `rest` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"push",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, want=2", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *Array:
+					length := len(arg.Elements)
+					newElements := make([]Object, length + 1)
+					copy(newElements, arg.Elements)
+					newElements[length] = args[1]
+					return &Array{Elements: newElements}
+				default:
+					return newError("argument to `push` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"int",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *Integer:
+					return arg
+				case *BigInt:
+					return arg
+				case *Float:
+					if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+						return newError("float %s cannot be converted to an integer", arg.Inspect())
+					}
+					value, _ := big.NewFloat(arg.Value).Int(nil)
+					return NewInteger(value)
+				case *String:
+					value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 0)
+					if !ok {
+						return newError("could not parse %q as integer", arg.Value)
+					}
+					return NewInteger(value)
+				default:
+					return newError("argument to `int` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"float",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *Integer, *BigInt:
+					value, _ := ToFloat(arg)
+					return &Float{Value: value}
+				case *Float:
+					return arg
+				case *String:
+					value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+					if err != nil {
+						return newError("could not parse %q as float", arg.Value)
+					}
+					return &Float{Value: value}
+				default:
+					return newError("argument to `float` not supported. got %s", args[0].Type())
+				}
+			},
+		},
+	},
+	{
+		"puts",
+		&Builtin{
+			Fn: func(args ...Object) Object {
+				for _, arg := range args {
+					fmt.Println(arg.Inspect())
+				}
+				return nil
+			},
+		},
+	},
+}
+
+func GetBuiltinByName(name string) *Builtin {
+	for _, def := range Builtins {
+		if def.Name == name {
+			return def.Builtin
+		}
+	}
+
+	return nil
+}
+
+func newError(format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
+}
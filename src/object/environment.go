@@ -2,12 +2,14 @@ package object
 
 type Environment struct {
 	store map[string]Object
+	constants map[string]bool
 	outer *Environment
 }
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	c := make(map[string]bool)
+	return &Environment{store: s, constants: c, outer: nil}
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
@@ -27,4 +29,46 @@ func (e *Environment) Get(name string) (Object, bool) {
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
-}
\ No newline at end of file
+}
+
+// SetConstant binds name like Set and marks the binding as one that cannot
+// be assigned to or redeclared in this environment.
+func (e *Environment) SetConstant(name string, val Object) Object {
+	e.store[name] = val
+	e.constants[name] = true
+	return val
+}
+
+// IsConstant reports whether the nearest binding of name is a constant.
+func (e *Environment) IsConstant(name string) bool {
+	if _, ok := e.store[name]; ok {
+		return e.constants[name]
+	}
+
+	if e.outer != nil {
+		return e.outer.IsConstant(name)
+	}
+
+	return false
+}
+
+// DefinesConstant reports whether this environment itself, ignoring the
+// outer ones, binds name as a constant.
+func (e *Environment) DefinesConstant(name string) bool {
+	return e.constants[name]
+}
+
+// Assign updates the binding of name in the nearest environment that has
+// one. It reports false when name is not bound anywhere.
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return val, true
+	}
+
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+
+	return nil, false
+}
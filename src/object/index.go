@@ -0,0 +1,268 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetIndex stores value at index in an array or hash, in place. Both engines
+// use it, so index assignment fails the same way in each of them.
+func SetIndex(left, index, value Object) error {
+	switch left := left.(type) {
+	case *Array:
+		i, ok := index.(*Integer)
+		if !ok {
+			return fmt.Errorf("array index must be INTEGER, got %s", index.Type())
+		}
+
+		length := int64(len(left.Elements))
+		idx, ok := ResolveIndex(i.Value, length)
+		if !ok {
+			return fmt.Errorf("index %d out of range for array of length %d", i.Value, length)
+		}
+
+		left.Elements[idx] = value
+		return nil
+	case *Hash:
+		key, ok := index.(Hashable)
+		if !ok {
+			return fmt.Errorf("unusable as hash key: %s", index.Type())
+		}
+
+		left.Pairs[key.HashKey()] = HashPair{Key: index, Value: value}
+		return nil
+	default:
+		return fmt.Errorf("index assignment not supported: %s", left.Type())
+	}
+}
+
+// ResolveIndex counts a negative index from the end, so -1 is the last
+// position, and reports whether the result lies within length.
+func ResolveIndex(i, length int64) (int64, bool) {
+	if i < 0 {
+		i += length
+	}
+
+	return i, i >= 0 && i < length
+}
+
+// SliceBetween implements a[start:end:step]. Like Python, bounds that are
+// null are left out, negative bounds count from the end and bounds past
+// either end are clamped, so it only fails on wrong types.
+func SliceBetween(left, start, end, step Object) (Object, error) {
+	var length int64
+	switch left := left.(type) {
+	case *Array:
+		length = int64(len(left.Elements))
+	case *String:
+		length = int64(len([]rune(left.Value)))
+	default:
+		return nil, fmt.Errorf("slice not supported: %s", left.Type())
+	}
+
+	r, err := sliceRange(length, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	return Slice(left, r)
+}
+
+// sliceRange turns slice bounds into the range of the positions they pick.
+func sliceRange(length int64, start, end, step Object) (*Range, error) {
+	bounds := []int64{0, 0, 1}
+	given := []bool{false, false, false}
+
+	for i, obj := range []Object{start, end, step} {
+		if obj.Type() == NULL_OBJ {
+			continue
+		}
+
+		integer, ok := obj.(*Integer)
+		if !ok {
+			return nil, fmt.Errorf("slice bounds must be INTEGER, got %s", obj.Type())
+		}
+		bounds[i], given[i] = integer.Value, true
+	}
+
+	r := &Range{Step: bounds[2]}
+	if r.Step == 0 {
+		return nil, fmt.Errorf("slice step cannot be zero")
+	}
+
+	// A negative step walks from the end down to just before position 0.
+	lower, upper := int64(0), length
+	if r.Step < 0 {
+		lower, upper = -1, length - 1
+	}
+
+	clamp := func(bound int64) int64 {
+		if bound < 0 {
+			bound += length
+			if bound < lower {
+				return lower
+			}
+			return bound
+		}
+
+		if bound > upper {
+			return upper
+		}
+		return bound
+	}
+
+	switch {
+	case given[0]:
+		r.Start = clamp(bounds[0])
+	case r.Step > 0:
+		r.Start = lower
+	default:
+		r.Start = upper
+	}
+
+	switch {
+	case given[1]:
+		r.End = clamp(bounds[1])
+	case r.Step > 0:
+		r.End = upper
+	default:
+		r.End = lower
+	}
+
+	return r, nil
+}
+
+// Slice picks the elements of an array, or the characters of a string, at
+// the positions a range produces.
+func Slice(left Object, r *Range) (Object, error) {
+	switch left := left.(type) {
+	case *Array:
+		// The positions are distinct and all have to be in bounds, so the
+		// slice is never longer than the array.
+		elements := make([]Object, 0, len(left.Elements))
+		for i := int64(0); ; i++ {
+			idx, ok := r.At(i)
+			if !ok {
+				break
+			}
+			if idx < 0 || idx >= int64(len(left.Elements)) {
+				return nil, fmt.Errorf("index %d out of range for array of length %d", idx, len(left.Elements))
+			}
+			elements = append(elements, left.Elements[idx])
+		}
+		return &Array{Elements: elements}, nil
+	case *String:
+		runes := []rune(left.Value)
+		sliced := make([]rune, 0, len(runes))
+		for i := int64(0); ; i++ {
+			idx, ok := r.At(i)
+			if !ok {
+				break
+			}
+			if idx < 0 || idx >= int64(len(runes)) {
+				return nil, fmt.Errorf("index %d out of range for string of length %d", idx, len(runes))
+			}
+			sliced = append(sliced, runes[idx])
+		}
+		return &String{Value: string(sliced)}, nil
+	default:
+		return nil, fmt.Errorf("slice not supported: %s", left.Type())
+	}
+}
+
+// Contains implements the in operator: a value of a range or an element of
+// an array, a substring of a string or a key of a hash.
+func Contains(container, item Object) (bool, error) {
+	switch container := container.(type) {
+	case *Range:
+		n, ok := item.(*Integer)
+		return ok && container.Contains(n.Value), nil
+	case *Array:
+		for _, el := range container.Elements {
+			if equal(el, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *String:
+		s, ok := item.(*String)
+		if !ok {
+			return false, fmt.Errorf("type mismatch: %s in STRING", item.Type())
+		}
+		return strings.Contains(container.Value, s.Value), nil
+	case *Hash:
+		key, ok := item.(Hashable)
+		if !ok {
+			return false, fmt.Errorf("unusable as hash key: %s", item.Type())
+		}
+		_, ok = container.Pairs[key.HashKey()]
+		return ok, nil
+	default:
+		return false, fmt.Errorf("in operator not supported: %s", container.Type())
+	}
+}
+
+// Spread implements ...value inside an array, where the elements of an
+// array or range are appended, and inside a hash, where the pairs of another
+// hash are merged in. target is a collection being built, so it is changed
+// in place.
+func Spread(target, value Object) error {
+	switch target := target.(type) {
+	case *Array:
+		switch value := value.(type) {
+		case *Array:
+			target.Elements = append(target.Elements, value.Elements...)
+		case *Range:
+			if _, err := value.Len(); err != nil {
+				return err
+			}
+			for i := int64(0); ; i++ {
+				n, ok := value.At(i)
+				if !ok {
+					break
+				}
+				target.Elements = append(target.Elements, &Integer{Value: n})
+			}
+		default:
+			return fmt.Errorf("spread operator not supported: %s", value.Type())
+		}
+	case *Hash:
+		hash, ok := value.(*Hash)
+		if !ok {
+			return fmt.Errorf("spread operator not supported in hash: %s", value.Type())
+		}
+		for key, pair := range hash.Pairs {
+			target.Pairs[key] = pair
+		}
+	default:
+		return fmt.Errorf("spread operator not supported: %s", target.Type())
+	}
+
+	return nil
+}
+
+// equal compares the way == does: numbers and strings by value, anything
+// else by identity.
+func equal(a, b Object) bool {
+	switch {
+	case IsInteger(a) && IsInteger(b):
+		return CompareIntegers(a, b) == 0
+	case IsNumber(a) && IsNumber(b):
+		af, _ := ToFloat(a)
+		bf, _ := ToFloat(b)
+		return af == bf
+	}
+
+	switch a := a.(type) {
+	case *String:
+		s, ok := b.(*String)
+		return ok && a.Value == s.Value
+	case *Boolean:
+		bb, ok := b.(*Boolean)
+		return ok && a.Value == bb.Value
+	case *Null:
+		return b.Type() == NULL_OBJ
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,136 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Iterator steps through a collection for a for loop. Every step produces a
+// key and a value: the index and element of an array, string or range, or the
+// key and value of a hash.
+type Iterator struct {
+	next func() (Object, Object, bool)
+
+	// keyed collections hand out their key when the loop asks for only one
+	// variable, everything else hands out its value.
+	keyed bool
+}
+
+func (it *Iterator) Inspect() string {
+	return "iterator"
+}
+
+func (it *Iterator) Type() ObjectType {
+	return ITERATOR_OBJ
+}
+
+// Next returns the loop variables of the next step, count of them, and false
+// once the collection is exhausted.
+func (it *Iterator) Next(count int) ([]Object, bool) {
+	key, value, ok := it.next()
+	if !ok {
+		return nil, false
+	}
+
+	if count == 2 {
+		return []Object{key, value}, true
+	}
+
+	if it.keyed {
+		return []Object{key}, true
+	}
+	return []Object{value}, true
+}
+
+func NewIterator(obj Object) (*Iterator, error) {
+	switch obj := obj.(type) {
+	case *Array:
+		// Reading the length on every step lets the loop see elements that
+		// are assigned while it runs.
+		i := 0
+		next := func() (Object, Object, bool) {
+			if i >= len(obj.Elements) {
+				return nil, nil, false
+			}
+			key := &Integer{Value: int64(i)}
+			value := obj.Elements[i]
+			i++
+			return key, value, true
+		}
+		return &Iterator{next: next}, nil
+	case *String:
+		runes := []rune(obj.Value)
+		i := 0
+		next := func() (Object, Object, bool) {
+			if i >= len(runes) {
+				return nil, nil, false
+			}
+			key := &Integer{Value: int64(i)}
+			value := &String{Value: string(runes[i])}
+			i++
+			return key, value, true
+		}
+		return &Iterator{next: next}, nil
+	case *Range:
+		i := int64(0)
+		next := func() (Object, Object, bool) {
+			value, ok := obj.At(i)
+			if !ok {
+				return nil, nil, false
+			}
+			key := &Integer{Value: i}
+			i++
+			return key, &Integer{Value: value}, true
+		}
+		return &Iterator{next: next}, nil
+	case *Hash:
+		pairs := obj.SortedPairs()
+		i := 0
+		next := func() (Object, Object, bool) {
+			if i >= len(pairs) {
+				return nil, nil, false
+			}
+			pair := pairs[i]
+			i++
+			return pair.Key, pair.Value, true
+		}
+		return &Iterator{next: next, keyed: true}, nil
+	default:
+		return nil, fmt.Errorf("%s is not iterable", obj.Type())
+	}
+}
+
+// SortedPairs returns the pairs of the hash ordered by key, so that walking
+// a hash gives the same order every time. Keys of different types are
+// grouped by type name.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return lessKey(pairs[i].Key, pairs[j].Key)
+	})
+
+	return pairs
+}
+
+func lessKey(a, b Object) bool {
+	if a.Type() != b.Type() {
+		return a.Type() < b.Type()
+	}
+
+	switch a := a.(type) {
+	case *Integer, *BigInt:
+		return CompareIntegers(a, b) < 0
+	case *Float:
+		return a.Value < b.(*Float).Value
+	case *String:
+		return a.Value < b.(*String).Value
+	case *Boolean:
+		return !a.Value && b.(*Boolean).Value
+	default:
+		return a.Inspect() < b.Inspect()
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"goblin/ast"
+	"goblin/code"
 	"goblin/color"
+	"goblin/token"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -16,6 +21,8 @@ var hashCache = make(map[string]HashKey)
 
 const (
 	INTEGER_OBJ = "INTEGER"
+	BIG_INTEGER_OBJ = "BIG_INTEGER"
+	FLOAT_OBJ = "FLOAT"
 	BOOLEAN_OBJ = "BOOLEAN"
 	STRING_OBJ = "STRING"
 	NULL_OBJ = "NULL"
@@ -27,6 +34,13 @@ const (
 	HASH_OBJ = "HASH"
 	QUOTE_OBJ = "QUOTE"
 	MACRO_OBJ = "MACRO"
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	CLOSURE_OBJ = "CLOSURE"
+	CELL_OBJ = "CELL"
+	ITERATOR_OBJ = "ITERATOR"
+	BREAK_OBJ = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
+	RANGE_OBJ = "RANGE"
 )
 
 type Object interface {
@@ -46,6 +60,37 @@ func (i *Integer) Type() ObjectType {
 	return INTEGER_OBJ
 }
 
+type BigInt struct {
+	Value *big.Int
+}
+
+func (bi *BigInt) Inspect() string {
+	return bi.Value.String()
+}
+
+func (bi *BigInt) Type() ObjectType {
+	return BIG_INTEGER_OBJ
+}
+
+type Float struct {
+	Value float64
+}
+
+// Inspect prints the shortest representation that parses back to the same
+// value, always keeping a decimal point so it reads back as a float.
+func (f *Float) Inspect() string {
+	out := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(out, ".eIN") {
+		out += ".0"
+	}
+
+	return out
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -80,6 +125,28 @@ func (rv *ReturnValue) Type() ObjectType {
 	return RETURN_VALUE_OBJ
 }
 
+// Break and Continue travel up through the blocks of a loop body in the
+// evaluator until they reach the loop, the same way ReturnValue does.
+type Break struct {}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+type Continue struct {}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
 type Error struct {
 	Message string
 }
@@ -93,27 +160,16 @@ func (e *Error) Type() ObjectType {
 }
 
 type Function struct {
+	Name string
 	Parameters []*ast.Identifier
+	Defaults []ast.Expression
+	Rest *ast.Identifier
 	Body *ast.BlockStatement
 	Env *Environment
 }
 
 func (f *Function) Inspect() string {
-	var out bytes.Buffer
-
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
-
-	out.WriteString("fn")
-	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") {\n")
-	out.WriteString(f.Body.String())
-	out.WriteString("\n}")
-	
-	return out.String()
+	return signature(f.Name, ast.ParameterStrings(f.Parameters, f.Defaults, f.Rest))
 }
 
 func (f *Function) Type() ObjectType {
@@ -201,6 +257,23 @@ func (i *Integer) HashKey() HashKey {
 	return getHashKey(i.Type(), uint64(i.Value))
 }
 
+func (bi *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(bi.Value.Text(16)))
+	return getHashKey(bi.Type(), h.Sum64())
+}
+
+// HashKey gives an integral float the key of the integer it equals, since
+// 2 == 2.0 has to find the same entry in a hash.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && !math.IsInf(f.Value, 0) {
+		value, _ := big.NewFloat(f.Value).Int(nil)
+		return NewInteger(value).(Hashable).HashKey()
+	}
+
+	return getHashKey(f.Type(), math.Float64bits(f.Value))
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
@@ -283,4 +356,53 @@ func (m *Macro) Inspect() string {
 
 func(m *Macro) Type() ObjectType {
 	return MACRO_OBJ
-}
\ No newline at end of file
+}
+
+type CompiledFunction struct {
+	Name string
+	Parameters []string
+	Instructions code.Instructions
+	Positions map[int]token.Token
+	NumLocals int
+	Arity *Arity
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+type Closure struct {
+	Fn *CompiledFunction
+	Free []*Cell
+}
+
+func (c *Closure) Inspect() string {
+	return signature(c.Fn.Name, c.Fn.Parameters)
+}
+
+func (c *Closure) Type() ObjectType {
+	return CLOSURE_OBJ
+}
+
+// Cell holds a variable that a closure has captured, so that the closure
+// and the scope it was created in keep sharing it after either assigns to
+// it. Cells never escape the vm as values.
+type Cell struct {
+	Value Object
+}
+
+func (c *Cell) Inspect() string {
+	if c.Value == nil {
+		return "Cell[]"
+	}
+
+	return fmt.Sprintf("Cell[%s]", c.Value.Inspect())
+}
+
+func (c *Cell) Type() ObjectType {
+	return CELL_OBJ
+}
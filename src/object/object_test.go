@@ -1,6 +1,12 @@
 package object
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -27,4 +33,319 @@ func TestStringHashKey(t *testing.T) {
 	if int1.HashKey() != int2.HashKey() {
 		t.Errorf("bools with the same content have different hash keys")
 	}
-}
\ No newline at end of file
+}
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value float64
+		expected string
+	}{
+		{1, "1.0"},
+		{-2, "-2.0"},
+		{0.1, "0.1"},
+		{1.0 / 3.0, "0.3333333333333333"},
+		{1e21, "1e+21"},
+		{2.5e-7, "2.5e-07"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. expected=%q, got=%q", tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		operator string
+		left int64
+		right int64
+		expected interface{}
+	}{
+		{"+", 1, 2, int64(3)},
+		{"-", -5, 10, int64(-15)},
+		{"*", -4, 5, int64(-20)},
+		{"/", 7, 2, int64(3)},
+		{"**", 3, 39, int64(4052555153018976267)},
+		{"**", -2, 63, int64(-9223372036854775808)},
+		{"**", 5, 0, int64(1)},
+		{"**", 2, -2, 0.25},
+		{"+", 9223372036854775807, 1, "9223372036854775808"},
+		{"-", -9223372036854775808, 1, "-9223372036854775809"},
+		{"*", 4611686018427387904, 2, "9223372036854775808"},
+		{"*", -1, -9223372036854775808, "9223372036854775808"},
+		{"/", -9223372036854775808, -1, "9223372036854775808"},
+		{"**", 2, 63, "9223372036854775808"},
+		{"**", 10, 19, "10000000000000000000"},
+		{"**", 3, 100, "515377520732011331036461129765621272702107522001"},
+		{"**", -1, 100000000001, int64(-1)},
+		{"**", 2, 1 << 20 + 1, fmt.Errorf("exponent too large: 2 ** 1048577")},
+		{"**", 2, 100000000, fmt.Errorf("exponent too large: 2 ** 100000000")},
+		{"**", -9223372036854775808, 100000, fmt.Errorf("exponent too large: -9223372036854775808 ** 100000")},
+	}
+
+	for _, tt := range tests {
+		result, err := IntegerArithmetic(tt.operator, tt.left, tt.right)
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			if err != nil {
+				t.Errorf("unexpected error for %d %s %d: %s", tt.left, tt.operator, tt.right, err)
+				continue
+			}
+			integer, ok := result.(*Integer)
+			if !ok || integer.Value != expected {
+				t.Errorf("wrong result for %d %s %d. expected=%d, got=%+v", tt.left, tt.operator, tt.right, expected, result)
+			}
+		case float64:
+			float, ok := result.(*Float)
+			if !ok || float.Value != expected {
+				t.Errorf("wrong result for %d %s %d. expected=%g, got=%+v", tt.left, tt.operator, tt.right, expected, result)
+			}
+		case error:
+			if err == nil || err.Error() != expected.Error() {
+				t.Errorf("wrong error for %d %s %d. expected=%q, got=%v", tt.left, tt.operator, tt.right, expected, err)
+			}
+		case string:
+			bigInt, ok := result.(*BigInt)
+			if !ok || bigInt.Inspect() != expected {
+				t.Errorf("wrong result for %d %s %d. expected=BigInt(%s), got=%+v", tt.left, tt.operator, tt.right, expected, result)
+			}
+		}
+	}
+}
+
+func TestBigIntDemotion(t *testing.T) {
+	huge, _ := new(big.Int).SetString("9223372036854775808", 10)
+
+	result, err := BigIntArithmetic("-", huge, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	integer, ok := result.(*Integer)
+	if !ok || integer.Value != 9223372036854775807 {
+		t.Errorf("result was not demoted to Integer. got=%T (%+v)", result, result)
+	}
+}
+
+func TestBigIntPowers(t *testing.T) {
+	huge, _ := new(big.Int).SetString("18446744073709551616", 10)
+	odd, _ := new(big.Int).SetString("18446744073709551617", 10)
+
+	tests := []struct {
+		base int64
+		exponent *big.Int
+		expected interface{}
+	}{
+		{0, huge, int64(0)},
+		{1, huge, int64(1)},
+		{-1, huge, int64(1)},
+		{-1, odd, int64(-1)},
+		{2, huge, fmt.Errorf("exponent too large: 2 ** 18446744073709551616")},
+		{-2, odd, fmt.Errorf("exponent too large: -2 ** 18446744073709551617")},
+	}
+
+	for _, tt := range tests {
+		result, err := BigIntArithmetic("**", big.NewInt(tt.base), tt.exponent)
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			if err != nil {
+				t.Errorf("unexpected error for %d ** %s: %s", tt.base, tt.exponent, err)
+				continue
+			}
+			integer, ok := result.(*Integer)
+			if !ok || integer.Value != expected {
+				t.Errorf("wrong result for %d ** %s. expected=%d, got=%+v", tt.base, tt.exponent, expected, result)
+			}
+		case error:
+			if err == nil || err.Error() != expected.Error() {
+				t.Errorf("wrong error for %d ** %s. expected=%q, got=%v", tt.base, tt.exponent, expected, err)
+			}
+		}
+	}
+}
+
+func TestBigIntHashKey(t *testing.T) {
+	first, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	second, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	other, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	if (&BigInt{Value: first}).HashKey() != (&BigInt{Value: second}).HashKey() {
+		t.Errorf("big integers with the same value have different hash keys")
+	}
+
+	if (&BigInt{Value: first}).HashKey() == (&BigInt{Value: other}).HashKey() {
+		t.Errorf("big integers with different values have the same hash key")
+	}
+}
+
+func TestFloatHashKey(t *testing.T) {
+	huge, _ := new(big.Int).SetString("1180591620717411303424", 10)
+
+	tests := []struct {
+		float float64
+		integer Hashable
+	}{
+		{2, &Integer{Value: 2}},
+		{0, &Integer{Value: 0}},
+		{math.Copysign(0, -1), &Integer{Value: 0}},
+		{-7, &Integer{Value: -7}},
+		{1 << 70, &BigInt{Value: huge}},
+	}
+
+	for _, tt := range tests {
+		if (&Float{Value: tt.float}).HashKey() != tt.integer.HashKey() {
+			t.Errorf("float %v has a different hash key than the integer it equals", tt.float)
+		}
+	}
+
+	if (&Float{Value: 2.5}).HashKey() == (&Float{Value: 2}).HashKey() {
+		t.Errorf("floats with different values have the same hash key")
+	}
+
+	if (&Float{Value: 2.5}).HashKey() != (&Float{Value: 2.5}).HashKey() {
+		t.Errorf("floats with the same value have different hash keys")
+	}
+}
+
+func TestIterators(t *testing.T) {
+	hash := &Hash{Pairs: map[HashKey]HashPair{}}
+	for _, k := range []Object{&String{Value: "b"}, &Integer{Value: 10}, &Integer{Value: 2}, &String{Value: "a"}} {
+		hash.Pairs[k.(Hashable).HashKey()] = HashPair{Key: k, Value: k}
+	}
+
+	tests := []struct {
+		iterable Object
+		count int
+		expected []string
+	}{
+		{&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, 1, []string{"1", "2"}},
+		{&Array{Elements: []Object{&Integer{Value: 7}}}, 2, []string{"0", "7"}},
+		{&String{Value: "hé"}, 1, []string{"h", "é"}},
+		{hash, 1, []string{"2", "10", "a", "b"}},
+		{&Range{Start: 5, End: 0, Step: -2}, 1, []string{"5", "3", "1"}},
+	}
+
+	for _, tt := range tests {
+		iterator, err := NewIterator(tt.iterable)
+		if err != nil {
+			t.Fatalf("NewIterator(%s) returned error: %s", tt.iterable.Inspect(), err)
+		}
+
+		got := []string{}
+		for {
+			values, ok := iterator.Next(tt.count)
+			if !ok {
+				break
+			}
+			for _, v := range values {
+				got = append(got, v.Inspect())
+			}
+		}
+
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("wrong iteration of %s. expected=%v, got=%v", tt.iterable.Inspect(), tt.expected, got)
+		}
+	}
+
+	_, err := NewIterator(&Boolean{Value: true})
+	if err == nil || err.Error() != "BOOLEAN is not iterable" {
+		t.Errorf("wrong error for BOOLEAN. got=%v", err)
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		r *Range
+		expectedLen int64
+		expectedString string
+		in []int64
+		out []int64
+	}{
+		{&Range{Start: 0, End: 10, Step: 1}, 10, "0..10", []int64{0, 9}, []int64{-1, 10}},
+		{&Range{Start: 0, End: 10, Step: 1, Inclusive: true}, 11, "0..=10", []int64{10}, []int64{11}},
+		{&Range{Start: 1, End: 10, Step: 3}, 3, "1..10 step 3", []int64{1, 4, 7}, []int64{2, 10}},
+		{&Range{Start: 10, End: 0, Step: -5, Inclusive: true}, 3, "10..=0 step -5", []int64{10, 5, 0}, []int64{-5, 15}},
+		{&Range{Start: 3, End: 3, Step: 1}, 0, "3..3", []int64{}, []int64{3}},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, 3, "-9223372036854775808..9223372036854775807 step 9223372036854775807", []int64{math.MinInt64, -1, math.MaxInt64 - 1}, []int64{math.MaxInt64}},
+	}
+
+	for _, tt := range tests {
+		length, err := tt.r.Len()
+		if err != nil || length != tt.expectedLen {
+			t.Errorf("%s has wrong length. expected=%d, got=%d (%v)", tt.r.Inspect(), tt.expectedLen, length, err)
+		}
+
+		if tt.r.Inspect() != tt.expectedString {
+			t.Errorf("wrong Inspect. expected=%q, got=%q", tt.expectedString, tt.r.Inspect())
+		}
+
+		for _, n := range tt.in {
+			if !tt.r.Contains(n) {
+				t.Errorf("%s does not contain %d", tt.r.Inspect(), n)
+			}
+		}
+
+		for _, n := range tt.out {
+			if tt.r.Contains(n) {
+				t.Errorf("%s contains %d", tt.r.Inspect(), n)
+			}
+		}
+	}
+}
+
+func TestHugeRanges(t *testing.T) {
+	tests := []struct {
+		r *Range
+		expectedError string
+		in []int64
+		index map[int64]int64
+	}{
+		{
+			&Range{Start: 0, End: math.MaxInt64, Step: 1, Inclusive: true},
+			"range 0..=9223372036854775807 has more than 9223372036854775807 values",
+			[]int64{0, math.MaxInt64},
+			map[int64]int64{0: 0, -1: math.MaxInt64, math.MaxInt64 - 1: math.MaxInt64 - 1},
+		},
+		{
+			&Range{Start: math.MinInt64, End: math.MaxInt64, Step: 1},
+			"range -9223372036854775808..9223372036854775807 has more than 9223372036854775807 values",
+			[]int64{math.MinInt64, 0, math.MaxInt64 - 1},
+			map[int64]int64{0: math.MinInt64, -1: math.MaxInt64 - 1, math.MaxInt64: -1, math.MinInt64: -1},
+		},
+		{
+			&Range{Start: math.MaxInt64, End: math.MinInt64, Step: -1, Inclusive: true},
+			"range 9223372036854775807..=-9223372036854775808 step -1 has more than 9223372036854775807 values",
+			[]int64{math.MinInt64, math.MaxInt64},
+			map[int64]int64{1: math.MaxInt64 - 1, -1: math.MinInt64},
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := tt.r.Len()
+		if err == nil || err.Error() != tt.expectedError {
+			t.Errorf("wrong error for the length of %s. expected=%q, got=%v", tt.r.Inspect(), tt.expectedError, err)
+		}
+
+		for _, n := range tt.in {
+			if !tt.r.Contains(n) {
+				t.Errorf("%s does not contain %d", tt.r.Inspect(), n)
+			}
+		}
+
+		for i, expected := range tt.index {
+			value, ok := tt.r.Index(i)
+			if !ok || value != expected {
+				t.Errorf("%s has wrong value at %d. expected=%d, got=%d (%t)", tt.r.Inspect(), i, expected, value, ok)
+			}
+		}
+
+		iterator, _ := NewIterator(tt.r)
+		values, ok := iterator.Next(1)
+		if !ok || values[0].(*Integer).Value != tt.r.Start {
+			t.Errorf("iterating %s does not start at %d. got=%v", tt.r.Inspect(), tt.r.Start, values)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Range is the lazy sequence start, start + step, ... up to end. It only
+// keeps its bounds, so even a huge range costs nothing until it is walked.
+type Range struct {
+	Start int64
+	End int64
+	Step int64
+	Inclusive bool
+}
+
+func (r *Range) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString(strconv.FormatInt(r.Start, 10))
+	if r.Inclusive {
+		out.WriteString("..=")
+	} else {
+		out.WriteString("..")
+	}
+	out.WriteString(strconv.FormatInt(r.End, 10))
+
+	if r.Step != 1 {
+		out.WriteString(" step ")
+		out.WriteString(strconv.FormatInt(r.Step, 10))
+	}
+
+	return out.String()
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+// NewRange builds the range for start..end, or start..=end when inclusive.
+// A missing step, nil or null, counts up by one.
+func NewRange(start, end, step Object, inclusive bool) (*Range, error) {
+	r := &Range{Step: 1, Inclusive: inclusive}
+
+	bounds := []struct {
+		obj Object
+		value *int64
+	}{
+		{start, &r.Start},
+		{end, &r.End},
+		{step, &r.Step},
+	}
+
+	for _, b := range bounds {
+		if b.obj == nil || b.obj.Type() == NULL_OBJ {
+			continue
+		}
+
+		integer, ok := b.obj.(*Integer)
+		if !ok {
+			return nil, fmt.Errorf("range bounds must be INTEGER, got %s", b.obj.Type())
+		}
+		*b.value = integer.Value
+	}
+
+	if r.Step == 0 {
+		return nil, fmt.Errorf("range step cannot be zero")
+	}
+
+	return r, nil
+}
+
+// span returns how many steps the last value of the range lies past its
+// start, or false when the range is empty. It is unsigned, since a range
+// over most of the int64 line spans more steps than an int64 holds.
+func (r *Range) span() (uint64, bool) {
+	first, last := r.Start, r.End
+	step := uint64(r.Step)
+	if r.Step < 0 {
+		first, last = r.End, r.Start
+		step = -step
+	}
+
+	if first > last || (first == last && !r.Inclusive) {
+		return 0, false
+	}
+
+	distance := uint64(last) - uint64(first)
+	if !r.Inclusive {
+		distance--
+	}
+
+	return distance / step, true
+}
+
+// Len counts the values of the range without producing them. A range can
+// hold more values than an int64 counts, which is reported as an error.
+func (r *Range) Len() (int64, error) {
+	span, ok := r.span()
+	if !ok {
+		return 0, nil
+	}
+
+	if span >= math.MaxInt64 {
+		return 0, fmt.Errorf("range %s has more than %d values", r.Inspect(), int64(math.MaxInt64))
+	}
+
+	return int64(span) + 1, nil
+}
+
+// At returns the i-th value of the range, or false when i is outside of it.
+func (r *Range) At(i int64) (int64, bool) {
+	span, ok := r.span()
+	if !ok || i < 0 || uint64(i) > span {
+		return 0, false
+	}
+
+	return r.Start + i * r.Step, true
+}
+
+// Index is At with negative positions counted from the end, the way ranges
+// are indexed.
+func (r *Range) Index(i int64) (int64, bool) {
+	if i >= 0 {
+		return r.At(i)
+	}
+
+	span, ok := r.span()
+	back := uint64(-i) - 1
+	if !ok || back > span {
+		return 0, false
+	}
+
+	return r.Start + int64(span - back) * r.Step, true
+}
+
+// Contains reports whether n is one of the values of the range.
+func (r *Range) Contains(n int64) bool {
+	span, ok := r.span()
+	if !ok {
+		return false
+	}
+
+	var distance uint64
+	if r.Step > 0 {
+		if n < r.Start {
+			return false
+		}
+		distance = uint64(n) - uint64(r.Start)
+	} else {
+		if n > r.Start {
+			return false
+		}
+		distance = uint64(r.Start) - uint64(n)
+	}
+
+	step := uint64(r.Step)
+	if r.Step < 0 {
+		step = -step
+	}
+
+	return distance % step == 0 && distance / step <= span
+}
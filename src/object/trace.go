@@ -0,0 +1,42 @@
+package object
+
+import (
+	"fmt"
+	"goblin/token"
+	"strings"
+)
+
+// MaxTraceCalls caps how many calls an error trace lists, so that runaway
+// recursion does not produce a message as deep as the stack.
+const MaxTraceCalls = 10
+
+const traceCall = "\n  in "
+const traceElided = "\n  ..."
+
+// Trace appends to the message of a runtime error the function it passed
+// through on its way out and the call that entered that function. Both
+// engines add the calls innermost first, so their traces read the same.
+func Trace(message string, name string, call token.Token) string {
+	if strings.HasSuffix(message, traceElided) {
+		return message
+	}
+	if strings.Count(message, traceCall) >= MaxTraceCalls {
+		return message + traceElided
+	}
+
+	if name == "" {
+		name = "anonymous function"
+	}
+
+	return message + fmt.Sprintf("%s%s, called at line %d, column %d", traceCall, name, call.Line, call.Column)
+}
+
+// signature prints a function by its name, if it has one, and parameters,
+// which is how both engines show a function.
+func signature(name string, params []string) string {
+	if name != "" {
+		name = " " + name
+	}
+
+	return "fn" + name + "(" + strings.Join(params, ", ") + ")"
+}
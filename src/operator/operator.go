@@ -0,0 +1,101 @@
+package operator
+
+import (
+	"goblin/code"
+	"goblin/token"
+)
+
+// Precedence levels, from loosest to tightest binding.
+const (
+	_int = iota
+	LOWEST
+	ASSIGN // =, +=, -=, *= or /=
+	PIPE // |>
+	LOGICAL_OR // ||
+	LOGICAL_AND // &&
+	EQUALS // ==, !=
+	LESSGREATER // >, <, >=, <= or in
+	RANGE // .. or ..=
+	BIT_OR // |
+	BIT_XOR // ^
+	BIT_AND // &
+	SHIFT // << or >>
+	SUM // + or -
+	PRODUCT // *, / or %
+	EXPONENT // **
+	PREFIX // -X, !X or ~X
+	CALL // myfunction(x)
+	INDEX
+)
+
+type Associativity int
+
+const (
+	Left Associativity = iota
+	Right
+)
+
+// Infix describes how a binary operator is parsed and compiled.
+type Infix struct {
+	Precedence int
+	Associativity Associativity
+
+	// Opcode is emitted after both operands have been compiled. For
+	// short-circuiting operators it is instead a conditional jump placed
+	// between the operands that skips the right one.
+	Opcode code.Opcode
+
+	// Swapped operators compile their right operand first, so that a < b
+	// can reuse the instruction for b > a.
+	Swapped bool
+	ShortCircuit bool
+}
+
+var Infixes = map[token.TokenType]Infix {
+	token.OR: {Precedence: LOGICAL_OR, Opcode: code.OpJumpTruthyOrPop, ShortCircuit: true},
+	token.AND: {Precedence: LOGICAL_AND, Opcode: code.OpJumpNotTruthyOrPop, ShortCircuit: true},
+	token.EQ: {Precedence: EQUALS, Opcode: code.OpEqual},
+	token.NOT_EQ: {Precedence: EQUALS, Opcode: code.OpNotEqual},
+	token.LT: {Precedence: LESSGREATER, Opcode: code.OpGreaterThan, Swapped: true},
+	token.GT: {Precedence: LESSGREATER, Opcode: code.OpGreaterThan},
+	token.LT_EQ: {Precedence: LESSGREATER, Opcode: code.OpGreaterThanEqual, Swapped: true},
+	token.GT_EQ: {Precedence: LESSGREATER, Opcode: code.OpGreaterThanEqual},
+	token.IN: {Precedence: LESSGREATER, Opcode: code.OpIn},
+	token.BIT_OR: {Precedence: BIT_OR, Opcode: code.OpBitOr},
+	token.BIT_XOR: {Precedence: BIT_XOR, Opcode: code.OpBitXor},
+	token.BIT_AND: {Precedence: BIT_AND, Opcode: code.OpBitAnd},
+	token.SHIFT_LEFT: {Precedence: SHIFT, Opcode: code.OpShiftLeft},
+	token.SHIFT_RIGHT: {Precedence: SHIFT, Opcode: code.OpShiftRight},
+	token.PLUS: {Precedence: SUM, Opcode: code.OpAdd},
+	token.MINUS: {Precedence: SUM, Opcode: code.OpSub},
+	token.ASTERISK: {Precedence: PRODUCT, Opcode: code.OpMul},
+	token.SLASH: {Precedence: PRODUCT, Opcode: code.OpDiv},
+	token.MODULO: {Precedence: PRODUCT, Opcode: code.OpMod},
+	token.EXPONENT: {Precedence: EXPONENT, Associativity: Right, Opcode: code.OpExp},
+}
+
+// Compound maps each compound assignment token to the binary operator it
+// applies before assigning.
+var Compound = map[token.TokenType]token.TokenType {
+	token.PLUS_ASSIGN: token.PLUS,
+	token.MINUS_ASSIGN: token.MINUS,
+	token.ASTERISK_ASSIGN: token.ASTERISK,
+	token.SLASH_ASSIGN: token.SLASH,
+}
+
+// Lookup returns the table entry for an infix operator token.
+func Lookup(t token.TokenType) (Infix, bool) {
+	infix, ok := Infixes[t]
+	return infix, ok
+}
+
+// RightBindingPower is the precedence the right operand of an infix
+// operator is parsed with. Lowering it by one for right-associative
+// operators lets an operator of the same level nest on the right.
+func (i Infix) RightBindingPower() int {
+	if i.Associativity == Right {
+		return i.Precedence - 1
+	}
+
+	return i.Precedence
+}
@@ -0,0 +1,40 @@
+package operator
+
+import (
+	"goblin/code"
+	"testing"
+)
+
+func TestInfixTable(t *testing.T) {
+	for tokenType, infix := range Infixes {
+		if infix.Precedence <= LOWEST || infix.Precedence >= PREFIX {
+			t.Errorf("operator %q has precedence %d outside of binary range", tokenType, infix.Precedence)
+		}
+
+		def, err := code.Lookup(byte(infix.Opcode))
+		if err != nil {
+			t.Errorf("operator %q: %s", tokenType, err)
+			continue
+		}
+
+		if infix.ShortCircuit && len(def.OperandWidths) != 1 {
+			t.Errorf("short-circuit operator %q must use a jump, got %s", tokenType, def.Name)
+		}
+	}
+}
+
+func TestRightBindingPower(t *testing.T) {
+	tests := []struct {
+		infix Infix
+		expected int
+	}{
+		{Infix{Precedence: SUM}, SUM},
+		{Infix{Precedence: EXPONENT, Associativity: Right}, EXPONENT - 1},
+	}
+
+	for _, tt := range tests {
+		if got := tt.infix.RightBindingPower(); got != tt.expected {
+			t.Errorf("wrong binding power. want=%d, got=%d", tt.expected, got)
+		}
+	}
+}
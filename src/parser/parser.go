@@ -1,42 +1,31 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"goblin/ast"
 	"goblin/lexer"
+	"goblin/operator"
 	"goblin/token"
+	"math/big"
 	"strconv"
 )
 
 const TRACE bool = false
 
-const (
-	_int = iota
-	LOWEST
-	EQUALS // ==, !=
-	LESSGREATER // >, <, >=, <=
-	SUM // + or -
-	PRODUCT // * or /
-	EXPONENT // **
-	PREFIX // -X or !X
-	CALL // myfunction(x)
-	INDEX
-)
-
+// precedences covers the infix tokens that are not binary operators;
+// everything else comes from the operator table.
 var precedences = map[token.TokenType]int {
-	token.EQ: EQUALS,
-	token.NOT_EQ: EQUALS,
-	token.LT: LESSGREATER,
-	token.GT: LESSGREATER,
-	token.LT_EQ: LESSGREATER,
-	token.GT_EQ: LESSGREATER,
-	token.PLUS: SUM,
-	token.MINUS: SUM,
-	token.ASTERISK: PRODUCT,
-	token.SLASH: PRODUCT,
-	token.EXPONENT: EXPONENT,
-	token.LPAREN: CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN: operator.ASSIGN,
+	token.PLUS_ASSIGN: operator.ASSIGN,
+	token.MINUS_ASSIGN: operator.ASSIGN,
+	token.ASTERISK_ASSIGN: operator.ASSIGN,
+	token.SLASH_ASSIGN: operator.ASSIGN,
+	token.PIPE: operator.PIPE,
+	token.RANGE: operator.RANGE,
+	token.RANGE_INCLUSIVE: operator.RANGE,
+	token.LPAREN: operator.CALL,
+	token.LBRACKET: operator.INDEX,
 }
 
 type (
@@ -49,8 +38,17 @@ type Parser struct {
 	curToken token.Token
 	peekToken token.Token
 	errors []string
+	warnings []string
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns map[token.TokenType]infixParseFn
+
+	// loopDepth counts the loops around the current token within the
+	// innermost function, which is where break and continue may appear.
+	loopDepth int
+
+	// letPattern is set while parsing the pattern of a destructuring let,
+	// where literal patterns are not allowed.
+	letPattern bool
 }
 
 
@@ -70,31 +68,39 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.SWITCH, p.parseSwitchExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.WHILE, p.parseWhileExpression)
+	p.registerPrefix(token.FOR, p.parseForExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.BIT_OR, p.parseLambda)
+	p.registerPrefix(token.OR, p.parseLambda)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERPOLATION, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
-	p.registerInfix(token.PLUS, p.parseInfixExpression)
-	p.registerInfix(token.MINUS, p.parseInfixExpression)
-	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
-	p.registerInfix(token.SLASH, p.parseInfixExpression)
-	p.registerInfix(token.LT, p.parseInfixExpression)
-	p.registerInfix(token.GT, p.parseInfixExpression)
-	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
-	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
-	p.registerInfix(token.EQ, p.parseInfixExpression)
-	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
-	p.registerInfix(token.EXPONENT, p.parseInfixExpression)
+	for tokenType := range operator.Infixes {
+		p.registerInfix(tokenType, p.parseInfixExpression)
+	}
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.RANGE, p.parseRangeExpression)
+	p.registerInfix(token.RANGE_INCLUSIVE, p.parseRangeExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 	return p
@@ -120,7 +126,14 @@ func (p *Parser) ParseProgram() *ast.Program {
 }
 
 func (p *Parser) Errors() []string {
-	return p.errors
+	all := append([]string{}, p.l.Errors()...)
+	return append(all, p.errors...)
+}
+
+// Warnings lists what is allowed but likely a mistake, such as a match
+// that can fall through all of its arms.
+func (p *Parser) Warnings() []string {
+	return p.warnings
 }
 
 //###############################################
@@ -128,10 +141,17 @@ func (p *Parser) Errors() []string {
 //###############################################
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
+	case token.FUNCTION:
+		if p.peekTokenIs(token.IDENT) {
+			return p.parseFunctionDeclaration()
+		}
+		return p.parseExpressionStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -140,11 +160,21 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		p.letPattern = true
+		stmt.Pattern = p.parsePattern()
+		p.letPattern = false
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -152,7 +182,11 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	p.nextToken()
 
-	stmt.Value = p.parseExpression(LOWEST)
+	stmt.Value = p.parseExpression(operator.LOWEST)
+
+	if lit, ok := stmt.Value.(*ast.FunctionLiteral); ok && stmt.Name != nil {
+		lit.Name = stmt.Name.Value
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -161,6 +195,48 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+// parseFunctionDeclaration parses fn name(params) { ... } into the let
+// binding of a function literal it stands for, keeping the fn token so that
+// the statement prints the way it was written.
+func (p *Parser) parseFunctionDeclaration() ast.Statement {
+	stmt := &ast.LetStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	lit, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	lit.Token = stmt.Token
+	lit.Name = stmt.Name.Value
+	stmt.Value = lit
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	if p.loopDepth == 0 {
+		msg := fmt.Sprintf("%s outside of loop at line %d, column %d", tok.Literal, tok.Line, tok.Column)
+		p.errors = append(p.errors, msg)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	if TRACE {
 		defer untrace(trace("parseExpressionStatement"))
@@ -168,7 +244,7 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
-	stmt.Expression = p.parseExpression(LOWEST)
+	stmt.Expression = p.parseExpression(operator.LOWEST)
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -181,7 +257,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
 	p.nextToken()
-	stmt.ReturnValue = p.parseExpression(LOWEST)
+	stmt.ReturnValue = p.parseExpression(operator.LOWEST)
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -228,8 +304,37 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if value, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			return &ast.BigIntLiteral{Token: p.curToken, Value: value}
+		}
+	}
+
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		msg := fmt.Sprintf("invalid integer literal %q at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	if TRACE {
+		defer untrace(trace("parseFloatLiteral"))
+	}
+
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		var msg string
+		if errors.Is(err, strconv.ErrRange) {
+			msg = fmt.Sprintf("float literal %s is out of range at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+		} else {
+			msg = fmt.Sprintf("invalid float literal %q at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+		}
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -246,6 +351,39 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	if TRACE {
+		defer untrace(trace("parseInterpolatedString"))
+	}
+
+	str := &ast.InterpolatedString{Token: p.curToken}
+
+	for {
+		if p.curToken.Literal != "" {
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		}
+
+		if p.curTokenIs(token.STRING) {
+			break
+		}
+
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(operator.LOWEST))
+
+		if p.peekTokenIs(token.EOF) {
+			// The lexer has already reported the ${ that was never closed.
+			return nil
+		}
+		if !p.peekTokenIs(token.STRING) && !p.peekTokenIs(token.INTERPOLATION) {
+			p.peekError(token.STRING)
+			return nil
+		}
+		p.nextToken()
+	}
+
+	return str
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	if TRACE {
 		defer untrace(trace("parsePrefixExpression"))
@@ -257,7 +395,7 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	}
 
 	p.nextToken()
-	expression.Right = p.parseExpression(PREFIX)
+	expression.Right = p.parseExpression(operator.PREFIX)
 	return expression
 }
 
@@ -272,9 +410,88 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left: left,
 	}
 
-	precedence := p.curPrecedence()
+	infix, _ := operator.Lookup(p.curToken.Type)
+	p.nextToken()
+	expression.Right = p.parseExpression(infix.RightBindingPower())
+
+	return expression
+}
+
+// parsePipeExpression turns x |> f(a) into the call f(x, a), and x |> f,
+// where the right side is not a call, into f(x). Nothing of the pipe is left
+// for the engines to handle.
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	p.nextToken()
+	right := p.parseExpression(operator.PIPE)
+
+	if call, ok := right.(*ast.CallExpression); ok {
+		args := append([]ast.Expression{left}, call.Arguments...)
+		return &ast.CallExpression{Token: call.Token, Function: call.Function, Arguments: args}
+	}
+
+	return &ast.CallExpression{Token: tok, Function: right, Arguments: []ast.Expression{left}}
+}
+
+// parseRangeExpression reads step as a word only right after the end of a
+// range, so it stays usable as an ordinary identifier everywhere else.
+func (p *Parser) parseRangeExpression(left ast.Expression) ast.Expression {
+	if TRACE {
+		defer untrace(trace("parseRangeExpression"))
+	}
+
+	expression := &ast.RangeExpression{Token: p.curToken, Start: left}
+
 	p.nextToken()
-	expression.Right = p.parseExpression(precedence)
+	expression.End = p.parseExpression(operator.RANGE)
+
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "step" {
+		p.nextToken()
+		p.nextToken()
+		expression.Step = p.parseExpression(operator.RANGE)
+	}
+
+	return expression
+}
+
+// parseAssignExpression parses the right-hand side one level below ASSIGN,
+// so that a = b = c assigns c to b before assigning the result to a. Only
+// plain = can target an index expression.
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	if TRACE {
+		defer untrace(trace("parseAssignExpression"))
+	}
+
+	if index, ok := left.(*ast.IndexExpression); ok {
+		expression := &ast.IndexAssignExpression{
+			Token: p.curToken,
+			Left: index.Left,
+			Index: index.Index,
+			Operator: p.curToken.Literal,
+		}
+
+		p.nextToken()
+		expression.Value = p.parseExpression(operator.ASSIGN - 1)
+
+		return expression
+	}
+
+	name, ok := left.(*ast.Identifier)
+	if !ok {
+		msg := fmt.Sprintf("cannot assign to %s at line %d, column %d", left.String(), p.curToken.Line, p.curToken.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	expression := &ast.AssignExpression{
+		Token: p.curToken,
+		Name: name,
+		Operator: p.curToken.Literal,
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(operator.ASSIGN - 1)
 
 	return expression
 }
@@ -286,7 +503,7 @@ func (p *Parser) parseBoolean() ast.Expression {
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 
-	exp := p.parseExpression(LOWEST)
+	exp := p.parseExpression(operator.LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
@@ -303,7 +520,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	}
 
 	p.nextToken()
-	expression.Condition = p.parseExpression(LOWEST)
+	expression.Condition = p.parseExpression(operator.LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
@@ -318,6 +535,19 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
 
+		// else if becomes an else block holding just the nested if.
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			block := &ast.BlockStatement{Token: p.curToken}
+			nested := p.parseIfExpression()
+			if nested == nil {
+				return nil
+			}
+			block.Statements = []ast.Statement{&ast.ExpressionStatement{Token: block.Token, Expression: nested}}
+			expression.Alternative = block
+			return expression
+		}
+
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -328,6 +558,303 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseSwitchExpression() ast.Expression {
+	expression := &ast.SwitchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(operator.LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+
+		switch p.curToken.Type {
+		case token.CASE:
+			c := &ast.SwitchCase{Token: p.curToken}
+
+			p.nextToken()
+			c.Values = append(c.Values, p.parseExpression(operator.LOWEST))
+			for p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+				p.nextToken()
+				c.Values = append(c.Values, p.parseExpression(operator.LOWEST))
+			}
+
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			c.Body = p.parseCaseBody()
+			expression.Cases = append(expression.Cases, c)
+		case token.DEFAULT:
+			if expression.Default != nil {
+				msg := fmt.Sprintf("duplicate default in switch at line %d, column %d", p.curToken.Line, p.curToken.Column)
+				p.errors = append(p.errors, msg)
+			}
+
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			expression.Default = p.parseCaseBody()
+		default:
+			msg := fmt.Sprintf("expected case or default, got %s at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+			p.errors = append(p.errors, msg)
+			p.parseCaseBody()
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(operator.LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		arm := &ast.MatchArm{Pattern: p.parsePattern()}
+		if arm.Pattern == nil {
+			return nil
+		}
+
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.parseExpression(operator.LOWEST)
+		}
+
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+
+		// A brace after the arrow starts a block, so a hash has to be
+		// wrapped in parentheses to be the result of an arm. Otherwise the
+		// body is a single statement, which lets an arm break or return.
+		isBlock := p.peekTokenIs(token.LBRACE)
+		p.nextToken()
+		if isBlock {
+			arm.Body = p.parseBlockStatement()
+		} else {
+			arm.Body = &ast.BlockStatement{Token: p.curToken}
+			if stmt := p.parseStatement(); stmt != nil {
+				arm.Body.Statements = []ast.Statement{stmt}
+			}
+		}
+
+		expression.Arms = append(expression.Arms, arm)
+
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		} else if !isBlock && !p.peekTokenIs(token.RBRACE) {
+			p.peekError(token.COMMA)
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	hasCatchAll := false
+	for _, arm := range expression.Arms {
+		hasCatchAll = hasCatchAll || arm.IsCatchAll()
+	}
+
+	if !hasCatchAll {
+		msg := fmt.Sprintf("match has no wildcard arm and yields null when no arm matches at line %d, column %d", expression.Token.Line, expression.Token.Column)
+		p.warnings = append(p.warnings, msg)
+	}
+
+	return expression
+}
+
+// parsePattern parses the pattern starting at the current token.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		// A literal has nothing to bind and could only fail on the left
+		// side of a let.
+		if p.letPattern {
+			msg := fmt.Sprintf("unexpected %s in let pattern at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		literal := p.parsePatternLiteral()
+		if literal == nil {
+			return nil
+		}
+		return &ast.LiteralPattern{Value: literal}
+	}
+}
+
+// parsePatternLiteral accepts numbers, with an optional minus, strings and
+// booleans, which is all a literal pattern or hash pattern key can be.
+func (p *Parser) parsePatternLiteral() ast.Expression {
+	switch p.curToken.Type {
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			break
+		}
+		fallthrough
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		return p.parseExpression(operator.PREFIX)
+	}
+
+	msg := fmt.Sprintf("unexpected %s in pattern at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		return pattern
+	}
+
+	for {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			pattern.HasRest = true
+
+			// A bare ... ignores the rest, the same as ..._ does.
+			if p.peekTokenIs(token.IDENT) {
+				p.nextToken()
+				if p.curToken.Literal != "_" {
+					pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+				}
+			}
+
+			// The rest takes whatever is left, so nothing can follow it.
+			if !p.expectPeek(token.RBRACKET) {
+				return nil
+			}
+			return pattern
+		}
+
+		element := p.parsePattern()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		// A bare name stands for the string key of the same name, and on
+		// its own it binds the value under that key to the name too.
+		var key ast.Expression
+		var value ast.Pattern
+		if p.curTokenIs(token.IDENT) {
+			key = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.peekTokenIs(token.COLON) {
+				value = p.parsePattern()
+			}
+		} else {
+			key = p.parsePatternLiteral()
+			if key == nil {
+				return nil
+			}
+		}
+
+		if value == nil {
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			p.nextToken()
+
+			value = p.parsePattern()
+			if value == nil {
+				return nil
+			}
+		}
+		pattern.Pairs = append(pattern.Pairs, &ast.HashPatternPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseCaseBody reads the statements after the colon of a case, which run
+// up to the next case, the default or the end of the switch.
+func (p *Parser) parseCaseBody() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	for !p.peekTokenIs(token.CASE) && !p.peekTokenIs(token.DEFAULT) && !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+	}
+
+	return block
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -351,41 +878,136 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.Defaults, lit.Rest = p.parseFunctionParameters(token.RPAREN)
+	if lit.Parameters == nil {
+		return nil
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
-	lit.Body = p.parseBlockStatement()
+	lit.Body = p.parseFunctionBody()
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parseLambda parses |params| body, and || body for a lambda without
+// parameters, into the function literal it is short for. A body that is not
+// a block is an expression, which the function returns.
+func (p *Parser) parseLambda() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: token.Token{Type: token.FUNCTION, Literal: "fn", Line: p.curToken.Line, Column: p.curToken.Column}}
+
+	if p.curTokenIs(token.OR) {
+		lit.Parameters = []*ast.Identifier{}
+	} else {
+		lit.Parameters, lit.Defaults, lit.Rest = p.parseFunctionParameters(token.BIT_OR)
+		if lit.Parameters == nil {
+			return nil
+		}
+	}
 
-	if p.peekTokenIs(token.RPAREN) {
+	if p.peekTokenIs(token.LBRACE) {
 		p.nextToken()
-		return identifiers
+		lit.Body = p.parseFunctionBody()
+		return lit
 	}
-	
+
 	p.nextToken()
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+	lit.Body = &ast.BlockStatement{Token: p.curToken}
 
-	for p.peekTokenIs(token.COMMA) {
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(operator.LOWEST)}
+	p.loopDepth = loopDepth
+
+	lit.Body.Statements = []ast.Statement{stmt}
+	return lit
+}
+
+// parseFunctionBody parses the body of a function or macro, which starts
+// outside of any loop no matter where the literal appears.
+func (p *Parser) parseFunctionBody() *ast.BlockStatement {
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	defer func() { p.loopDepth = loopDepth }()
+
+	return p.parseBlockStatement()
+}
+
+// parseLoopBody parses a block in which break and continue are allowed.
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+
+	return p.parseBlockStatement()
+}
+
+// parseFunctionParameters returns the parameters up to end, their defaults
+// and the rest parameter. Defaults is nil unless some parameter has one, and
+// once one does, all that follow it need one too.
+func (p *Parser) parseFunctionParameters(end token.TokenType) ([]*ast.Identifier, []ast.Expression, *ast.Identifier) {
+	identifiers := []*ast.Identifier{}
+	var defaults []ast.Expression
+	var rest *ast.Identifier
+
+	if p.peekTokenIs(end) {
 		p.nextToken()
+		return identifiers, nil, nil
+	}
+
+	for {
 		p.nextToken()
 
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil, nil
+			}
+			rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+			// The rest takes whatever is left, so nothing can follow it.
+			break
+		}
+
+		if !p.curTokenIs(token.IDENT) {
+			msg := fmt.Sprintf("unexpected %s in parameters at line %d, column %d", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
+			p.errors = append(p.errors, msg)
+			return nil, nil, nil
+		}
+
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
+
+		var value ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			// A default has to stop at the end of the list, even when that
+			// is the | of a lambda.
+			value = p.parseExpression(precedenceOf(end))
+
+			if defaults == nil {
+				defaults = make([]ast.Expression, len(identifiers) - 1)
+			}
+		} else if defaults != nil {
+			msg := fmt.Sprintf("parameter %s without a default follows one with a default at line %d, column %d", ident.Value, ident.Token.Line, ident.Token.Column)
+			p.errors = append(p.errors, msg)
+		}
+
+		if defaults != nil {
+			defaults = append(defaults, value)
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+	if !p.expectPeek(end) {
+		return nil, nil, nil
 	}
 
-	return identifiers
+	return identifiers, defaults, rest
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -402,13 +1024,34 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 		return args
 	}
 
-	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
-
-	for p.peekTokenIs(token.COMMA) {
+	named := false
+	for {
 		p.nextToken()
+
+		// name: value passes an argument by name, which has to come after
+		// all positional ones.
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.NamedArgument{Token: p.curToken}
+			arg.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+			p.nextToken()
+			p.nextToken()
+			arg.Value = p.parseExpression(operator.LOWEST)
+
+			args = append(args, arg)
+			named = true
+		} else {
+			if named {
+				msg := fmt.Sprintf("positional argument after named argument at line %d, column %d", p.curToken.Line, p.curToken.Column)
+				p.errors = append(p.errors, msg)
+			}
+			args = append(args, p.parseElement())
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
 	}
 
 	if !p.expectPeek(token.RPAREN) {
@@ -435,12 +1078,12 @@ func (p *Parser) parseExpressionList(endToken token.TokenType) []ast.Expression
 	}
 
 	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
+	list = append(list, p.parseElement())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
+		list = append(list, p.parseElement())
 	}
 
 	if !p.expectPeek(endToken) {
@@ -450,16 +1093,68 @@ func (p *Parser) parseExpressionList(endToken token.TokenType) []ast.Expression
 	return list
 }
 
+// parseElement parses an element of a list, which may spread the elements
+// of another one into it with ...value.
+func (p *Parser) parseElement() ast.Expression {
+	if !p.curTokenIs(token.ELLIPSIS) {
+		return p.parseExpression(operator.LOWEST)
+	}
+
+	return p.parseSpreadExpression()
+}
+
+func (p *Parser) parseSpreadExpression() *ast.SpreadExpression {
+	spread := &ast.SpreadExpression{Token: p.curToken}
+
+	p.nextToken()
+	spread.Value = p.parseExpression(operator.LOWEST)
+
+	return spread
+}
+
+// parseIndexExpression turns into a slice as soon as a colon shows up
+// between the brackets.
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
+
+	var index ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		index = p.parseExpression(operator.LOWEST)
+	}
+
+	if !p.peekTokenIs(token.COLON) {
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return &ast.IndexExpression{Token: tok, Left: left, Index: index}
+	}
+
+	slice := &ast.SliceExpression{Token: tok, Left: left, Start: index}
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	slice.End = p.parseSliceBound()
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		slice.Step = p.parseSliceBound()
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
-	return exp
+	return slice
+}
+
+// parseSliceBound parses the bound after a colon, which is nil when the
+// next token already ends it.
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekTokenIs(token.COLON) || p.peekTokenIs(token.RBRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	return p.parseExpression(operator.LOWEST)
 }
 
 func (p *Parser) parseHashLiteral() ast.Expression {
@@ -468,14 +1163,23 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 	for !p.peekTokenIs(token.RBRACE) {
 		p.nextToken()
-		key := p.parseExpression(LOWEST)
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			hash.Spreads = append(hash.Spreads, p.parseSpreadExpression())
+			if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			continue
+		}
+
+		key := p.parseExpression(operator.LOWEST)
 
 		if !p.expectPeek(token.COLON) {
 			return nil
 		}
 		p.nextToken()
 	
-		value := p.parseExpression(LOWEST)
+		value := p.parseExpression(operator.LOWEST)
 		hash.Pairs[key] = value
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -496,13 +1200,25 @@ func (p *Parser) parseMacroLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	params, defaults, rest := p.parseFunctionParameters(token.RPAREN)
+	if params == nil {
+		return nil
+	}
+
+	// Macros get their arguments quoted one by one, so they take neither
+	// defaults nor a rest parameter.
+	if defaults != nil || rest != nil {
+		msg := fmt.Sprintf("macro parameters cannot have defaults or a rest parameter at line %d, column %d", lit.Token.Line, lit.Token.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	lit.Parameters = params
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
-	lit.Body = p.parseBlockStatement()
+	lit.Body = p.parseFunctionBody()
 	return lit
 }
 
@@ -514,7 +1230,7 @@ func (p *Parser) parseWhileExpression() ast.Expression {
 	}
 
 	p.nextToken()
-	while.Condition = p.parseExpression(LOWEST)
+	while.Condition = p.parseExpression(operator.LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
@@ -523,11 +1239,50 @@ func (p *Parser) parseWhileExpression() ast.Expression {
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
-	while.Loop = p.parseBlockStatement()
+	while.Loop = p.parseLoopBody()
 
 	return while
 }
 
+func (p *Parser) parseForExpression() ast.Expression {
+	expression := &ast.ForExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expression.Names = []*ast.Identifier{{Token: p.curToken, Value: p.curToken.Literal}}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		expression.Names = append(expression.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Iterable = p.parseExpression(operator.LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Body = p.parseLoopBody()
+
+	return expression
+}
+
 //###############################################
 // Helper Functions 
 //###############################################
@@ -536,20 +1291,24 @@ func (p *Parser) nextToken() {
 	p.peekToken = p.l.NextToken()
 }
 
-func (p *Parser) curPrecedence() int {
-	if p, ok := precedences[p.curToken.Type]; ok {
+func precedenceOf(t token.TokenType) int {
+	if infix, ok := operator.Lookup(t); ok {
+		return infix.Precedence
+	}
+
+	if p, ok := precedences[t]; ok {
 		return p
 	}
 
-	return LOWEST
+	return operator.LOWEST
 }
 
-func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
-		return p
-	}
+func (p *Parser) curPrecedence() int {
+	return precedenceOf(p.curToken.Type)
+}
 
-	return LOWEST
+func (p *Parser) peekPrecedence() int {
+	return precedenceOf(p.peekToken.Type)
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
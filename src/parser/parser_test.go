@@ -44,6 +44,180 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedName string
+		expectedOperator string
+		expectedValue interface{}
+	}{
+		{"x = 5;", "x", "=", 5},
+		{"y += true;", "y", "+=", true},
+		{"total -= step", "total", "-=", "step"},
+		{"x *= 2", "x", "*=", 2},
+		{"x /= 2", "x", "/=", 2},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		assign, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if !testIdentifier(t, assign.Name, tt.expectedName) {
+			return
+		}
+
+		if assign.Operator != tt.expectedOperator {
+			t.Errorf("assign.Operator is not %q. got=%q", tt.expectedOperator, assign.Operator)
+		}
+
+		if !testLiteralExpression(t, assign.Value, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestIndexAssignExpression(t *testing.T) {
+	input := `myArray[1 + 1] = x * 2`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	assign, ok := stmt.Expression.(*ast.IndexAssignExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IndexAssignExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, assign.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, assign.Index, 1, "+", 1) {
+		return
+	}
+
+	if !testInfixExpression(t, assign.Value, "x", "*", 2) {
+		return
+	}
+
+	expected := "(myArray[(1 + 1)] = (x * 2))"
+	if assign.String() != expected {
+		t.Errorf("assign.String() wrong. expected=%q, got=%q", expected, assign.String())
+	}
+}
+
+func TestCompoundIndexAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		operator string
+		expected string
+	}{
+		{"a[0] += 1", "+=", "(a[0] += 1)"},
+		{"h[\"k\"] -= x * 2", "-=", "(h[k] -= (x * 2))"},
+		{"a[i][j] *= 3", "*=", "((a[i])[j] *= 3)"},
+		{"a[0] /= b[1] = 2", "/=", "(a[0] /= (b[1] = 2))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		assign, ok := stmt.Expression.(*ast.IndexAssignExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.IndexAssignExpression. got=%T", stmt.Expression)
+		}
+
+		if assign.Operator != tt.operator {
+			t.Errorf("assign.Operator is not %q. got=%q", tt.operator, assign.Operator)
+		}
+
+		if assign.String() != tt.expected {
+			t.Errorf("assign.String() wrong. expected=%q, got=%q", tt.expected, assign.String())
+		}
+	}
+}
+
+func TestAssignExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"1 = 2", "cannot assign to 1 at line 1, column 3"},
+		{"a + b = c", "cannot assign to (a + b) at line 1, column 7"},
+		{"f() += 1", "cannot assign to f() at line 1, column 5"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("parser has wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(errors), errors)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	input := `const limit = 10;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.LetStatement. got=%T", program.Statements[0])
+	}
+
+	if !stmt.IsConst() {
+		t.Errorf("stmt is not a const binding")
+	}
+
+	if !testIdentifier(t, stmt.Name, "limit") {
+		return
+	}
+
+	if !testLiteralExpression(t, stmt.Value, 10) {
+		return
+	}
+
+	if stmt.String() != "const limit = 10;" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input string
@@ -143,6 +317,125 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralPrefixesAndSeparators(t *testing.T) {
+	tests := []struct {
+		input string
+		expected int64
+	}{
+		{"1_000_000", 1000000},
+		{"0x1F", 31},
+		{"0XfF", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"0b_1111_0000", 240},
+		{"0x7FFF_FFFF_FFFF_FFFF", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp is not *ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %d. got=%d", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestBigIntLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{"9223372036854775808", "9223372036854775808"},
+		{"0xFFFF_FFFF_FFFF_FFFF_F", "295147905179352825855"},
+		{"1_000_000_000_000_000_000_000", "1000000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.BigIntLiteral)
+		if !ok {
+			t.Fatalf("exp is not *ast.BigIntLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value.String() != tt.expected {
+			t.Errorf("literal.Value not %s. got=%s", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestIntegerLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"let x = 1;\nlet y = 0xFFFF__FFFF_FFFF_FFFF_F;", `invalid integer literal "0xFFFF__FFFF_FFFF_FFFF_F" at line 2, column 9`},
+		{"0x", `invalid integer literal "0x" at line 1, column 1`},
+		{"0b102", `invalid integer literal "0b102" at line 1, column 1`},
+		{"1__000", `invalid integer literal "1__000" at line 1, column 1`},
+		{"12abc", `invalid integer literal "12abc" at line 1, column 1`},
+		{"1.5e", `invalid float literal "1.5e" at line 1, column 1`},
+		{"1e400", "float literal 1e400 is out of range at line 1, column 1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("parser has wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(errors), errors)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1_000.25", 1000.25},
+		{"1e3", 1000},
+		{"2.5e-3", 0.0025},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp is not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := `"Hello, World!";`
 
@@ -174,6 +467,52 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStringExpression(t *testing.T) {
+	input := `"hello ${name}, ${1 + 2}!";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp is not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	if len(str.Parts) != 5 {
+		t.Fatalf("str.Parts does not contain 5 parts. got=%d", len(str.Parts))
+	}
+
+	expectedStrings := map[int]string{0: "hello ", 2: ", ", 4: "!"}
+	for i, expected := range expectedStrings {
+		literal, ok := str.Parts[i].(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("str.Parts[%d] is not *ast.StringLiteral. got=%T", i, str.Parts[i])
+		}
+
+		if literal.Value != expected {
+			t.Errorf("literal.Value not %q. got=%q", expected, literal.Value)
+		}
+	}
+
+	testIdentifier(t, str.Parts[1], "name")
+	testInfixExpression(t, str.Parts[3], 1, "+", 2)
+
+	if str.String() != "hello ${name}, ${(1 + 2)}!" {
+		t.Errorf("str.String() wrong. got=%q", str.String())
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := "true;"
 
@@ -217,6 +556,7 @@ func TestParsingPrefixExpressiongs(t *testing.T) {
 		{"-foobar;", "-", "foobar"},
 		{"!true;", "!", true},
 		{"!false;", "!", false},
+		{"~5;", "~", 5},
 	}
 
 	for _, tt := range prefixTests {
@@ -282,6 +622,12 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"true != false;", true, "!=", false},
 		{"false == false;", false, "==", false},
 		{"5 ** 2", 5, "**", 2},
+		{"5 % 2", 5, "%", 2},
+		{"5 & 2", 5, "&", 2},
+		{"5 | 2", 5, "|", 2},
+		{"5 ^ 2", 5, "^", 2},
+		{"5 << 2", 5, "<<", 2},
+		{"5 >> 2", 5, ">>", 2},
 	}
 
 	for _, tt := range infixTests {
@@ -314,6 +660,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a == b && c != d || e",
+			"(((a == b) && (c != d)) || e)",
+		},
+		{
+			"a && b & c",
+			"(a && (b & c))",
+		},
+		{
+			"xs |> map(f) |> filter(g)",
+			"filter(map(xs, f), g)",
+		},
+		{
+			"a + b |> f(c * d)",
+			"f((a + b), (c * d))",
+		},
+		{
+			"x = a |> f",
+			"(x = f(a))",
+		},
+		{
+			"a || b |> f()",
+			"f((a || b))",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -426,6 +800,90 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(5 * 2 ** 3)",
 			"add((5 * (2 ** 3)))",
 		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"a = b = c || d",
+			"(a = (b = (c || d)))",
+		},
+		{
+			"a += b * c",
+			"(a += (b * c))",
+		},
+		{
+			"a * b ** c ** d * e",
+			"((a * (b ** (c ** d))) * e)",
+		},
+		{
+			"a - b - c",
+			"((a - b) - c)",
+		},
+		{
+			"a % 2 == 0",
+			"((a % 2) == 0)",
+		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"x & 1 == 0",
+			"((x & 1) == 0)",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"1 << 2 + 3",
+			"(1 << (2 + 3))",
+		},
+		{
+			"a & b << c",
+			"(a & (b << c))",
+		},
+		{
+			"~a & b",
+			"((~a) & b)",
+		},
+		{
+			"a >> 1 < b",
+			"((a >> 1) < b)",
+		},
+		{
+			"a[1:n - 1]",
+			"(a[1:(n - 1)])",
+		},
+		{
+			"a[:]",
+			"(a[:])",
+		},
+		{
+			"a[::-1][0]",
+			"((a[::(-1)])[0])",
+		},
+		{
+			"a[-2:]",
+			"(a[(-2):])",
+		},
+		{
+			"0..n - 1",
+			"(0..(n - 1))",
+		},
+		{
+			"x in 1..=10 step 2 == true",
+			"((x in (1..=10 step 2)) == true)",
+		},
+		{
+			"a[1..len(a)]",
+			"(a[(1..len(a))])",
+		},
+		{
+			"let step = 2; 0..10 step step",
+			"let step = 2;(0..10 step step)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -472,72 +930,309 @@ func TestIfExpression(t *testing.T) {
 		t.Errorf("consequense is not 1 statement. got=%d\n", len(exp.Consequense.Statements))
 	}
 
-	consequence, ok := exp.Consequense.Statements[0].(*ast.ExpressionStatement)
+	consequence, ok := exp.Consequense.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("exp.Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequense.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative.Statements was not nil. got=%+v", exp.Alternative)
+	}
+}
+
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { y }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statement(s). got=%d\n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statments[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(exp.Consequense.Statements) != 1 {
+		t.Errorf("consequense is not 1 statement. got=%d\n", len(exp.Consequense.Statements))
+	}
+
+	consequence, ok := exp.Consequense.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("exp.Consequence.Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequense.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if len(exp.Alternative.Statements) != 1 {
+		t.Errorf("alternative is not 1 statement. got=%d\n", len(exp.Consequense.Statements))
+	}
+
+	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("exp.Alternative.Statements[0] is not ast.ExpressionStatement. got=%T", exp.Alternative.Statements[0])
+	}
+
+	if !testIdentifier(t, alternative.Expression, "y") {
+		return
+	}
+
+}
+
+func TestElseIfExpression(t *testing.T) {
+	input := `if (a) { 1 } else if (b) { 2 } else { 3 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("alternative is not 1 statement. got=%d", len(exp.Alternative.Statements))
+	}
+
+	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("alternative is not ast.ExpressionStatement. got=%T", exp.Alternative.Statements[0])
+	}
+
+	nested, ok := alternative.Expression.(*ast.IfExpression)
 	if !ok {
-		t.Fatalf("exp.Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequense.Statements[0])
+		t.Fatalf("alternative is not ast.IfExpression. got=%T", alternative.Expression)
 	}
 
-	if !testIdentifier(t, consequence.Expression, "x") {
+	if !testIdentifier(t, nested.Condition, "b") {
 		return
 	}
 
-	if exp.Alternative != nil {
-		t.Errorf("exp.Alternative.Statements was not nil. got=%+v", exp.Alternative)
+	if nested.Alternative == nil {
+		t.Errorf("nested.Alternative is nil")
 	}
 }
 
-func TestIfElseExpression(t *testing.T) {
-	input := `if (x < y) { x } else { y }`
+func TestSwitchExpression(t *testing.T) {
+	input := `switch (x) { case 1, 2: a; b case 3: default: c }`
 
 	l := lexer.New(input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParseErrors(t, p)
 
-	if len(program.Statements) != 1 {
-		t.Fatalf("program.Statements does not contain %d statement(s). got=%d\n", 1, len(program.Statements))
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.SwitchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.SwitchExpression. got=%T", stmt.Expression)
 	}
 
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("program.Statments[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	if !testIdentifier(t, exp.Value, "x") {
+		return
 	}
 
-	exp, ok := stmt.Expression.(*ast.IfExpression)
-	if !ok {
-		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	if len(exp.Cases) != 2 {
+		t.Fatalf("wrong number of cases. expected=2, got=%d", len(exp.Cases))
 	}
 
-	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
-		return
+	tests := []struct {
+		values []interface{}
+		statements int
+	}{
+		{[]interface{}{1, 2}, 2},
+		{[]interface{}{3}, 0},
 	}
 
-	if len(exp.Consequense.Statements) != 1 {
-		t.Errorf("consequense is not 1 statement. got=%d\n", len(exp.Consequense.Statements))
+	for i, tt := range tests {
+		c := exp.Cases[i]
+		if len(c.Values) != len(tt.values) {
+			t.Fatalf("case %d has wrong number of values. expected=%d, got=%d", i, len(tt.values), len(c.Values))
+		}
+
+		for j, v := range tt.values {
+			testLiteralExpression(t, c.Values[j], v)
+		}
+
+		if len(c.Body.Statements) != tt.statements {
+			t.Errorf("case %d has wrong number of statements. expected=%d, got=%d", i, tt.statements, len(c.Body.Statements))
+		}
 	}
 
-	consequence, ok := exp.Consequense.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("exp.Consequence.Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequense.Statements[0])
+	if exp.Default == nil || len(exp.Default.Statements) != 1 {
+		t.Fatalf("default is not 1 statement. got=%+v", exp.Default)
 	}
 
-	if !testIdentifier(t, consequence.Expression, "x") {
-		return
+	expected := "switchx { case 1, 2: ab case 3:  default: c }"
+	if exp.String() != expected {
+		t.Errorf("exp.String() wrong. expected=%q, got=%q", expected, exp.String())
 	}
+}
 
-	if len(exp.Alternative.Statements) != 1 {
-		t.Errorf("alternative is not 1 statement. got=%d\n", len(exp.Consequense.Statements))
+func TestSwitchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"switch (x) { default: 1 default: 2 }", "duplicate default in switch at line 1, column 25"},
+		{"switch (x) { 1 }", "expected case or default, got 1 at line 1, column 14"},
 	}
 
-	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("exp.Alternative.Statements[0] is not ast.ExpressionStatement. got=%T", exp.Alternative.Statements[0])
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("parser has wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(errors), errors)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
 	}
+}
 
-	if !testIdentifier(t, alternative.Expression, "y") {
-		return
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+		warnings int
+	}{
+		{
+			`match (x) { 1 => a, -2.5 => b, "s" => c, true => d, _ => e }`,
+			"matchx { 1 => a, (-2.5) => b, s => c, true => d, _ => e }",
+			0,
+		},
+		{
+			`match (x) { [first, ...rest] => first, [] => 0, [a, ..._] => a }`,
+			"matchx { [first, ...rest] => first, [] => 0, [a, ..._] => a }",
+			1,
+		},
+		{
+			`match (x) { {"type": "add", "x": x} => x, {} => 0, n if n > 0 => { n; n } }`,
+			"matchx { {type: add, x: x} => x, {} => 0, n if (n > 0) => nn }",
+			1,
+		},
+		{
+			`match (x) { n => n }`,
+			"matchx { n => n }",
+			0,
+		},
+		{
+			`match (x) { [first, ...] => first, [_, ...] => 0 }`,
+			"matchx { [first, ..._] => first, [_, ..._] => 0 }",
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.MatchExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+		}
+
+		if exp.String() != tt.expected {
+			t.Errorf("exp.String() wrong. expected=%q, got=%q", tt.expected, exp.String())
+		}
+
+		if len(p.Warnings()) != tt.warnings {
+			t.Errorf("wrong number of warnings for %q. expected=%d, got=%d (%v)", tt.input, tt.warnings, len(p.Warnings()), p.Warnings())
+		}
+	}
+}
+
+func TestLetPatterns(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{"let [a, b, ...rest] = xs;", "let [a, b, ...rest] = xs;"},
+		{"let [_, [x, y]] = xs;", "let [_, [x, y]] = xs;"},
+		{"let {name, age: years} = person;", "let {name: name, age: years} = person;"},
+		{`const {"p": [a, ..._]} = h;`, "const {p: [a, ..._]} = h;"},
+		{"let [first, ...] = xs;", "let [first, ..._] = xs;"},
+		{"let [...] = xs;", "let [..._] = xs;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.Name != nil || stmt.Pattern == nil {
+			t.Fatalf("stmt has no pattern. got Name=%v, Pattern=%v", stmt.Name, stmt.Pattern)
+		}
+
+		if stmt.String() != tt.expected {
+			t.Errorf("stmt.String() wrong. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"match (x) { a + 1 => 1 }", "expected next token to be =>, got + instead"},
+		{"match (x) { (a) => 1 }", "unexpected ( in pattern at line 1, column 13"},
+		{"match (x) { [...a, b] => 1 }", "expected next token to be ], got , instead"},
+		{"match (x) { [..., b] => 1 }", "expected next token to be ], got , instead"},
+		{"match (x) { [...1] => 1 }", "expected next token to be ], got INT instead"},
+		{"match (x) { {(a): 1} => 1 }", "unexpected ( in pattern at line 1, column 14"},
+		{"let [a, 1] = xs;", "unexpected 1 in let pattern at line 1, column 9"},
+		{`let {"k": "v"} = h;`, "unexpected v in let pattern at line 1, column 11"},
 	}
 
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("parser has no errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
 }
 
 func TestFunctionLiteralParsing(t *testing.T) {
@@ -610,6 +1305,139 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionParameterDefaults(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{"fn(x, y = 10) { x }", "fn(x, y = 10) x"},
+		{"fn(first, ...others) { first }", "fn(first, ...others) first"},
+		{"fn(a = 1 + 2, ...r) { a }", "fn(a = (1 + 2), ...r) a"},
+		{"f(1, y: 2, z: x + 1)", "f(1, y: 2, z: (x + 1))"},
+		{"f(...args, 1, y: 2)", "f(...args, 1, y: 2)"},
+		{"[...a, 1, ...b + c]", "[...a, 1, ...(b + c)]"},
+		{`{...defaults, "k": v}`, "{...defaults, k:v}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestLambdaParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{"|x| x * 2", "fn(x) (x * 2)"},
+		{"|a, b = 1| { a; b }", "fn(a, b = 1) ab"},
+		{"|| 1", "fn() 1"},
+		{"|...xs| len(xs)", "fn(...xs) len(xs)"},
+		{"map(xs, |x| x + 1)", "map(xs, fn(x) (x + 1))"},
+		{"|x| |y| x | y", "fn(x) fn(y) (x | y)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if stmt.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedString string
+		expectedName string
+	}{
+		{"fn add(a, b = 1) { a + b }", "fn add(a, b = 1) (a + b)", "add"},
+		{"fn noop() {};", "fn noop() ", "noop"},
+		{"let twice = fn(x) { x * 2 }", "let twice = fn(x) (x * 2);", "twice"},
+		{"const id = |x| x", "const id = fn(x) x;", "id"},
+		{"let f = g", "let f = g;", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("stmt is not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.String() != tt.expectedString {
+			t.Errorf("expected=%q, got=%q", tt.expectedString, stmt.String())
+		}
+
+		lit, ok := stmt.Value.(*ast.FunctionLiteral)
+		if !ok {
+			if tt.expectedName != "" {
+				t.Errorf("stmt.Value is not *ast.FunctionLiteral. got=%T", stmt.Value)
+			}
+			continue
+		}
+
+		if lit.Name != tt.expectedName {
+			t.Errorf("lit.Name is not %q. got=%q", tt.expectedName, lit.Name)
+		}
+	}
+
+	program := New(lexer.New("fn(x) { x }(1)")).ParseProgram()
+	if _, ok := program.Statements[0].(*ast.ExpressionStatement); !ok {
+		t.Errorf("anonymous function is not an expression statement. got=%T", program.Statements[0])
+	}
+}
+
+func TestFunctionParameterErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"fn(x = 1, y) { x }", "parameter y without a default follows one with a default at line 1, column 11"},
+		{"fn(...r, x) { x }", "expected next token to be ), got , instead"},
+		{"fn(1) { 1 }", "unexpected 1 in parameters at line 1, column 4"},
+		{"macro(...r) { r }", "macro parameters cannot have defaults or a rest parameter at line 1, column 1"},
+		{"f(x: 1, 2)", "positional argument after named argument at line 1, column 9"},
+		{"|1| x", "unexpected 1 in parameters at line 1, column 2"},
+		{"while (true) { let f = || if (true) { break } }", "break outside of loop at line 1, column 39"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("parser has no errors for %q", tt.input)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := `add(1, 2 * 3, 4 + 5);`
 
@@ -908,6 +1736,103 @@ func TestWhileExpression(t *testing.T) {
 		return
 	}
 }
+func TestForExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedNames []string
+		expectedString string
+	}{
+		{"for (x in xs) { x }", []string{"x"}, "for(x in xs) { x }"},
+		{"for (k, v in h) { break; }", []string{"k", "v"}, "for(k, v in h) { break; }"},
+		{"for (x in xs) { if (x) { continue; } }", []string{"x"}, "for(x in xs) { ifx continue; }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		exp, ok := stmt.Expression.(*ast.ForExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.ForExpression. got=%T", stmt.Expression)
+		}
+
+		if len(exp.Names) != len(tt.expectedNames) {
+			t.Fatalf("wrong number of loop variables. expected=%d, got=%d", len(tt.expectedNames), len(exp.Names))
+		}
+
+		for i, name := range tt.expectedNames {
+			testIdentifier(t, exp.Names[i], name)
+		}
+
+		if exp.String() != tt.expectedString {
+			t.Errorf("exp.String() wrong. expected=%q, got=%q", tt.expectedString, exp.String())
+		}
+	}
+}
+
+func TestLoopControlErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"break;", "break outside of loop at line 1, column 1"},
+		{"if (true) { continue; }", "continue outside of loop at line 1, column 13"},
+		{"while (true) { fn() { break; } }", "break outside of loop at line 1, column 23"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("parser has wrong number of errors for %q. expected=1, got=%d (%v)", tt.input, len(errors), errors)
+		}
+
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	tests := []struct {
+		input string
+		expected string
+	}{
+		{`let s = "unterminated;`, "unterminated string literal at line 1, column 9"},
+		{`let s = "abc ${}";`, "empty interpolation at line 1, column 14"},
+		{`let s = "abc ${ 1`, "unterminated interpolation at line 1, column 14"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("parser has wrong number of errors. expected=1, got=%d (%v)", len(errors), errors)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
 //###############################################
 // Helper Functions 
 //###############################################
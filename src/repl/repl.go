@@ -6,6 +6,7 @@ import (
 	"goblin/color"
 	"goblin/compiler"
 	"goblin/lexer"
+	"goblin/object"
 	"goblin/parser"
 	"goblin/vm"
 	"io"
@@ -36,6 +37,8 @@ func Start(in io.Reader, out io.Writer) {
 	fmt.Printf("Hello %s! This is the Goblin programming language!\n", user.Username)
 	fmt.Printf("Feel free to type in commands\n")
 
+	s := newSession()
+
 	for {
 		fmt.Fprint(out, color.ColorWrapper(color.GREEN, PROMPT))
 		scanned := scanner.Scan()
@@ -49,33 +52,72 @@ func Start(in io.Reader, out io.Writer) {
 			return
 		}
 
-		l := lexer.New(line)
-		p := parser.New(l)
-		program := p.ParseProgram()
+		execute(out, line, s)
+	}
+}
 
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
-			continue
-		}
+// session holds what one line leaves behind for the next ones.
+type session struct {
+	constants []object.Object
+	globals []object.Object
+	symbolTable *compiler.SymbolTable
+}
 
-		comp := compiler.New()
-		err := comp.Compile(program)
-		if err != nil {
-			fmt.Fprintf(out, "whoops! Compilation failed: \n %s\n", err)
-			continue
-		}
-		
-		machine := vm.New(comp.Bytecode())
-		err = machine.Run()
-		if err != nil {
-			fmt.Fprintf(out, "whoops! Executing bytecode failed: \n %s\n", err)
-			continue
+func newSession() *session {
+	symbolTable := compiler.NewSymbolTable()
+	for i, v := range object.Builtins {
+		symbolTable.DefineBuiltin(i, v.Name)
+	}
+
+	return &session{
+		constants: []object.Object{},
+		globals: make([]object.Object, vm.GlobalsSize),
+		symbolTable: symbolTable,
+	}
+}
+
+// execute runs a single line of input. Any Go panic raised while doing so is
+// reported instead of ending the session.
+func execute(out io.Writer, line string, s *session) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(out, "whoops! Goblin crashed: \n %v\n", r)
 		}
-		
-		lastPopped := machine.LastPoppedStackElem()
-		io.WriteString(out, lastPopped.Inspect())
-		io.WriteString(out, "\n")
+	}()
+
+	l := lexer.New(line)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return
+	}
+
+	for _, msg := range p.Warnings() {
+		io.WriteString(out, color.ColorWrapper(color.YELLOW, "warning: " + msg + "\n"))
+	}
+
+	comp := compiler.NewWithState(s.symbolTable, s.constants)
+	err := comp.Compile(program)
+	if err != nil {
+		fmt.Fprintf(out, "whoops! Compilation failed: \n %s\n", err)
+		return
 	}
+
+	bytecode := comp.Bytecode()
+	s.constants = bytecode.Constants
+	
+	machine := vm.NewWithGlobalsState(bytecode, s.globals)
+	err = machine.Run()
+	if err != nil {
+		fmt.Fprintf(out, "whoops! Executing bytecode failed: \n %s\n", err)
+		return
+	}
+	
+	lastPopped := machine.LastPoppedStackElem()
+	io.WriteString(out, lastPopped.Inspect())
+	io.WriteString(out, "\n")
 }
 
 func printParserErrors(out io.Writer, errors[]string) {
@@ -5,6 +5,8 @@ type TokenType string
 type Token struct{
 	Type TokenType
 	Literal string
+	Line int
+	Column int
 }
 
 const (
@@ -15,12 +17,14 @@ const (
 
 	IDENT = "IDENT"
 	INT = "INT"
+	FLOAT = "FLOAT"
 	IF = "IF"
 	ELSE = "ELSE"
 	RETURN = "RETURN"
 	TRUE = "TRUE"
 	FALSE = "FALSE"
 	STRING = "STRING"
+	INTERPOLATION = "INTERPOLATION"
 
 	// Operators
 	
@@ -37,6 +41,24 @@ const (
 	GT_EQ = ">="
 	LT_EQ = "<="
 	EXPONENT = "**"
+	MODULO = "%"
+	BIT_AND = "&"
+	BIT_OR = "|"
+	BIT_XOR = "^"
+	BIT_NOT = "~"
+	SHIFT_LEFT = "<<"
+	SHIFT_RIGHT = ">>"
+	AND = "&&"
+	OR = "||"
+	PIPE = "|>"
+	PLUS_ASSIGN = "+="
+	MINUS_ASSIGN = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN = "/="
+	RANGE = ".."
+	RANGE_INCLUSIVE = "..="
+	ELLIPSIS = "..."
+	ARROW = "=>"
 
 
 	// Delimiters
@@ -56,13 +78,23 @@ const (
 
 	FUNCTION = "FUNCTION"
 	LET = "LET"
+	CONST = "CONST"
 	WHILE = "WHILE"
 	MACRO = "MACRO"
+	FOR = "FOR"
+	IN = "IN"
+	BREAK = "BREAK"
+	CONTINUE = "CONTINUE"
+	SWITCH = "SWITCH"
+	CASE = "CASE"
+	DEFAULT = "DEFAULT"
+	MATCH = "MATCH"
 )
 
 var keywords = map[string]TokenType {
 	"fn": FUNCTION,
 	"let": LET,
+	"const": CONST,
 	"if": IF,
 	"else": ELSE,
 	"return": RETURN,
@@ -70,6 +102,14 @@ var keywords = map[string]TokenType {
 	"false": FALSE,
 	"while": WHILE,
 	"macro": MACRO,
+	"for": FOR,
+	"in": IN,
+	"break": BREAK,
+	"continue": CONTINUE,
+	"switch": SWITCH,
+	"case": CASE,
+	"default": DEFAULT,
+	"match": MATCH,
 }
 
 func LookUpIdent(ident string) TokenType {
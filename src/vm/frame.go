@@ -0,0 +1,23 @@
+package vm
+
+import (
+	"goblin/code"
+	"goblin/object"
+)
+
+type Frame struct {
+	cl *object.Closure
+	ip int
+	basePointer int
+	// loops holds the stack pointer at the entry of each loop the frame is
+	// in, innermost last, for break and continue to unwind to.
+	loops []int
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
@@ -1,32 +1,95 @@
 package vm
 
 import (
+	"errors"
 	"fmt"
 	"goblin/code"
 	"goblin/compiler"
 	"goblin/object"
-	"math"
+	"goblin/token"
+	"strings"
 )
 
 const StackSize = 2048
+const GlobalsSize = 65536
+const MaxFrames = 1024
 
 var True = &object.Boolean{Value: true}
 var False = &object.Boolean{Value: false}
+var Null = &object.Null{}
+
+var arithmeticOperators = map[code.Opcode]string{
+	code.OpAdd: "+",
+	code.OpSub: "-",
+	code.OpMul: "*",
+	code.OpDiv: "/",
+	code.OpExp: "**",
+	code.OpMod: "%",
+	code.OpBitAnd: "&",
+	code.OpBitOr: "|",
+	code.OpBitXor: "^",
+	code.OpShiftLeft: "<<",
+	code.OpShiftRight: ">>",
+}
 
 type VM struct {
 	constants []object.Object
-	instructions code.Instructions
+
 	stack []object.Object
 	sp int
+
+	globals []object.Object
+
+	frames []*Frame
+	framesIndex int
 }
 
 func New(bytecode *compiler.Bytecode) *VM {
+	mainFn := &object.CompiledFunction{
+		Instructions: bytecode.Instructions,
+		Positions: bytecode.Positions,
+	}
+	mainClosure := &object.Closure{Fn: mainFn}
+	mainFrame := NewFrame(mainClosure, 0)
+
+	frames := make([]*Frame, MaxFrames)
+	frames[0] = mainFrame
+
 	return &VM{
-		instructions: bytecode.Instructions,
 		constants: bytecode.Constants,
 		stack: make([]object.Object, StackSize),
 		sp: 0,
+		globals: make([]object.Object, GlobalsSize),
+		frames: frames,
+		framesIndex: 1,
+	}
+}
+
+// NewWithGlobalsState lets the repl keep the values of globals defined on
+// previous lines.
+func NewWithGlobalsState(bytecode *compiler.Bytecode, s []object.Object) *VM {
+	vm := New(bytecode)
+	vm.globals = s
+	return vm
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex - 1]
+}
+
+func (vm *VM) pushFrame(f *Frame) error {
+	if vm.framesIndex >= MaxFrames {
+		return fmt.Errorf("stack overflow")
 	}
+
+	vm.frames[vm.framesIndex] = f
+	vm.framesIndex++
+	return nil
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
 }
 
 func (vm *VM) StackTop() object.Object {
@@ -38,21 +101,31 @@ func (vm *VM) StackTop() object.Object {
 }
 
 func (vm *VM) Run() error {
-	for pc := 0; pc < len(vm.instructions); pc++ {
-		op := code.Opcode(vm.instructions[pc])
+	var ip int
+	var ins code.Instructions
+	var op code.Opcode
+
+	for vm.currentFrame().ip < len(vm.currentFrame().Instructions()) - 1 {
+		vm.currentFrame().ip++
+
+		ip = vm.currentFrame().ip
+		ins = vm.currentFrame().Instructions()
+		op = code.Opcode(ins[ip])
+
 		switch op {
 		case code.OpConstant:
-			constIndex := code.ReadUint16(vm.instructions[pc + 1:])
-			pc += 2
+			constIndex := code.ReadUint16(ins[ip + 1:])
+			vm.currentFrame().ip += 2
 			
 			err := vm.push(vm.constants[constIndex])
 			if err != nil {
 				return err
 			}
-		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpExp:
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpExp, code.OpMod,
+			code.OpBitAnd, code.OpBitOr, code.OpBitXor, code.OpShiftLeft, code.OpShiftRight:
 			err := vm.executeBinaryOperation(op)
 			if err != nil  {
-				return err
+				return vm.runtimeError(ip, err)
 			}
 		case code.OpPop:
 			vm.pop()
@@ -66,27 +139,719 @@ func (vm *VM) Run() error {
 			if err != nil {
 				return err
 			}
+		case code.OpNull:
+			err := vm.push(Null)
+			if err != nil {
+				return err
+			}
 		case code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpGreaterThanEqual:
 			err := vm.executeComparison(op)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpIn:
+			container := vm.pop()
+			item := vm.pop()
+
+			found, err := object.Contains(container, item)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+
+			err = vm.push(nativeBoolToBooleanObject(found))
+			if err != nil {
+				return err
+			}
+		case code.OpRange:
+			inclusive := code.ReadUint8(ins[ip + 1:]) == 1
+			vm.currentFrame().ip += 1
+
+			step := vm.pop()
+			end := vm.pop()
+			start := vm.pop()
+
+			r, err := object.NewRange(start, end, step, inclusive)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+
+			err = vm.push(r)
 			if err != nil {
 				return err
 			}
 		case code.OpMinus:
 			err := vm.executeMinusOperator()
 			if err != nil {
-				return err
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpBitNot:
+			err := vm.executeBitNotOperator()
+			if err != nil {
+				return vm.runtimeError(ip, err)
 			}
 		case code.OpBang:
 			err := vm.executeBangOperator()
 			if err != nil {
 				return err
 			}
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip = pos - 1
+		case code.OpJumpIfBound:
+			pos := int(code.ReadUint16(ins[ip + 1:]))
+			localIndex := code.ReadUint8(ins[ip + 3:])
+			vm.currentFrame().ip += 3
+
+			if vm.stack[vm.currentFrame().basePointer + int(localIndex)] != nil {
+				vm.currentFrame().ip = pos - 1
+			}
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip += 2
+
+			condition := vm.pop()
+			if !isTruthy(condition) {
+				vm.currentFrame().ip = pos - 1
+			}
+		case code.OpJumpNotTruthyOrPop, code.OpJumpTruthyOrPop:
+			pos := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip += 2
+
+			if isTruthy(vm.StackTop()) == (op == code.OpJumpTruthyOrPop) {
+				vm.currentFrame().ip = pos - 1
+			} else {
+				vm.pop()
+			}
+		case code.OpInterpolate:
+			numParts := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip += 2
+
+			err := vm.executeInterpolation(numParts)
+			if err != nil {
+				return err
+			}
+		case code.OpSetGlobal:
+			globalIndex := code.ReadUint16(ins[ip + 1:])
+			vm.currentFrame().ip += 2
+
+			// Like a local, a global of a block that a closure has captured
+			// lives in a cell.
+			if cell, ok := vm.globals[globalIndex].(*object.Cell); ok {
+				cell.Value = vm.pop()
+			} else {
+				vm.globals[globalIndex] = vm.pop()
+			}
+		case code.OpGetGlobal:
+			globalIndex := code.ReadUint16(ins[ip + 1:])
+			vm.currentFrame().ip += 2
+
+			value := vm.globals[globalIndex]
+			if cell, ok := value.(*object.Cell); ok {
+				value = cell.Value
+			}
+			if value == nil {
+				return vm.runtimeError(ip, vm.undeclaredError(ip))
+			}
+
+			err := vm.push(value)
+			if err != nil {
+				return err
+			}
+		case code.OpSetLocal:
+			localIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			slot := vm.currentFrame().basePointer + int(localIndex)
+			value := vm.pop()
+
+			// A local that a closure has captured lives in a cell, which
+			// has to be updated in place so the closure sees the change.
+			if cell, ok := vm.stack[slot].(*object.Cell); ok {
+				cell.Value = value
+			} else {
+				vm.stack[slot] = value
+			}
+		case code.OpGetLocal:
+			localIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			value := vm.stack[vm.currentFrame().basePointer + int(localIndex)]
+			if cell, ok := value.(*object.Cell); ok {
+				value = cell.Value
+			}
+			if value == nil {
+				return vm.runtimeError(ip, vm.undeclaredError(ip))
+			}
+
+			err := vm.push(value)
+			if err != nil {
+				return err
+			}
+		case code.OpGetBuiltin:
+			builtinIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			definition := object.Builtins[builtinIndex]
+
+			err := vm.push(definition.Builtin)
+			if err != nil {
+				return err
+			}
+		case code.OpGetFree:
+			freeIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			value := vm.currentFrame().cl.Free[freeIndex].Value
+			if value == nil {
+				return vm.runtimeError(ip, vm.undeclaredError(ip))
+			}
+
+			err := vm.push(value)
+			if err != nil {
+				return err
+			}
+		case code.OpSetFree:
+			freeIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			currentClosure := vm.currentFrame().cl
+			currentClosure.Free[freeIndex].Value = vm.pop()
+		case code.OpCaptureLocal:
+			localIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			slot := vm.currentFrame().basePointer + int(localIndex)
+			cell, ok := vm.stack[slot].(*object.Cell)
+			if !ok {
+				cell = &object.Cell{Value: vm.stack[slot]}
+				vm.stack[slot] = cell
+			}
+
+			err := vm.push(cell)
+			if err != nil {
+				return err
+			}
+		case code.OpCaptureGlobal:
+			globalIndex := code.ReadUint16(ins[ip + 1:])
+			vm.currentFrame().ip += 2
+
+			cell, ok := vm.globals[globalIndex].(*object.Cell)
+			if !ok {
+				cell = &object.Cell{Value: vm.globals[globalIndex]}
+				vm.globals[globalIndex] = cell
+			}
+
+			err := vm.push(cell)
+			if err != nil {
+				return err
+			}
+		case code.OpClearGlobals:
+			first := int(code.ReadUint16(ins[ip + 1:]))
+			count := int(code.ReadUint16(ins[ip + 3:]))
+			vm.currentFrame().ip += 4
+
+			clear(vm.globals[first:first + count])
+		case code.OpClearLocals:
+			first := vm.currentFrame().basePointer + int(code.ReadUint8(ins[ip + 1:]))
+			count := int(code.ReadUint8(ins[ip + 2:]))
+			vm.currentFrame().ip += 2
+
+			clear(vm.stack[first:first + count])
+		case code.OpCaptureFree:
+			freeIndex := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			currentClosure := vm.currentFrame().cl
+			err := vm.push(currentClosure.Free[freeIndex])
+			if err != nil {
+				return err
+			}
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip += 2
+
+			array := vm.buildArray(vm.sp - numElements, vm.sp)
+			vm.sp = vm.sp - numElements
+
+			err := vm.push(array)
+			if err != nil {
+				return err
+			}
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip += 2
+
+			hash, err := vm.buildHash(vm.sp - numElements, vm.sp)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+			vm.sp = vm.sp - numElements
+
+			err = vm.push(hash)
+			if err != nil {
+				return err
+			}
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+
+			err := vm.executeIndexExpression(left, index)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpDup:
+			err := vm.push(vm.stack[vm.sp - 1])
+			if err != nil {
+				return err
+			}
+		case code.OpDupPair:
+			err := vm.push(vm.stack[vm.sp - 2])
+			if err != nil {
+				return err
+			}
+
+			err = vm.push(vm.stack[vm.sp - 2])
+			if err != nil {
+				return err
+			}
+		case code.OpMatchArray:
+			length := int(code.ReadUint16(ins[ip + 1:]))
+			hasRest := code.ReadUint8(ins[ip + 3:]) == 1
+			vm.currentFrame().ip += 3
+
+			matched := false
+			if array, ok := vm.stack[vm.sp - 1].(*object.Array); ok {
+				n := len(array.Elements)
+				matched = n == length || (hasRest && n > length)
+			}
+
+			err := vm.push(nativeBoolToBooleanObject(matched))
+			if err != nil {
+				return err
+			}
+		case code.OpMatchHash:
+			numKeys := int(code.ReadUint16(ins[ip + 1:]))
+			vm.currentFrame().ip += 2
+
+			keys := vm.stack[vm.sp - numKeys:vm.sp]
+			vm.sp = vm.sp - numKeys
+
+			matched := false
+			if hash, ok := vm.stack[vm.sp - 1].(*object.Hash); ok {
+				matched = true
+				for _, key := range keys {
+					if _, ok := hash.Pairs[key.(object.Hashable).HashKey()]; !ok {
+						matched = false
+						break
+					}
+				}
+			}
+
+			err := vm.push(nativeBoolToBooleanObject(matched))
+			if err != nil {
+				return err
+			}
+		case code.OpDestructureArray:
+			if value := vm.stack[vm.sp - 1]; value.Type() != object.ARRAY_OBJ {
+				return vm.runtimeError(ip, fmt.Errorf("cannot destructure %s as ARRAY", value.Type()))
+			}
+		case code.OpDestructureHash:
+			if value := vm.stack[vm.sp - 1]; value.Type() != object.HASH_OBJ {
+				return vm.runtimeError(ip, fmt.Errorf("cannot destructure %s as HASH", value.Type()))
+			}
+		case code.OpSpread:
+			value := vm.pop()
+
+			err := object.Spread(vm.stack[vm.sp - 1], value)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpSlice:
+			step := vm.pop()
+			end := vm.pop()
+			start := vm.pop()
+			left := vm.pop()
+
+			sliced, err := object.SliceBetween(left, start, end, step)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+
+			err = vm.push(sliced)
+			if err != nil {
+				return err
+			}
+		case code.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			err := object.SetIndex(left, index, value)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+
+			err = vm.push(value)
+			if err != nil {
+				return err
+			}
+		case code.OpIterInit:
+			iterator, err := object.NewIterator(vm.pop())
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+
+			err = vm.push(iterator)
+			if err != nil {
+				return err
+			}
+		case code.OpIterNext:
+			pos := int(code.ReadUint16(ins[ip + 1:]))
+			count := int(code.ReadUint8(ins[ip + 3:]))
+			vm.currentFrame().ip += 3
+
+			iterator := vm.stack[vm.sp - 1].(*object.Iterator)
+			values, ok := iterator.Next(count)
+			if !ok {
+				vm.pop()
+				vm.currentFrame().ip = pos - 1
+				break
+			}
+
+			for _, v := range values {
+				err := vm.push(v)
+				if err != nil {
+					return err
+				}
+			}
+		case code.OpLoopEnter:
+			frame := vm.currentFrame()
+			frame.loops = append(frame.loops, vm.sp)
+		case code.OpLoopExit:
+			frame := vm.currentFrame()
+			frame.loops = frame.loops[:len(frame.loops) - 1]
+		case code.OpUnwind:
+			frame := vm.currentFrame()
+			vm.sp = frame.loops[len(frame.loops) - 1]
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip + 1:])
+			vm.currentFrame().ip += 1
+
+			err := vm.executeCall(int(numArgs), nil)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpCallNamed:
+			numArgs := code.ReadUint8(ins[ip + 1:])
+			namesIndex := code.ReadUint16(ins[ip + 2:])
+			vm.currentFrame().ip += 3
+
+			err := vm.executeCall(int(numArgs), vm.argumentNames(int(namesIndex)))
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpCallSpread:
+			namesIndex := code.ReadUint16(ins[ip + 1:])
+			vm.currentFrame().ip += 2
+
+			// The positional arguments come as an array below the named
+			// ones; both are laid out on the stack for the call.
+			names := vm.argumentNames(int(namesIndex))
+			named := append([]object.Object{}, vm.stack[vm.sp - len(names):vm.sp]...)
+			args := vm.stack[vm.sp - len(names) - 1].(*object.Array).Elements
+			vm.sp = vm.sp - len(names) - 1
+
+			for _, arg := range args {
+				err := vm.push(arg)
+				if err != nil {
+					return vm.runtimeError(ip, err)
+				}
+			}
+			for _, arg := range named {
+				err := vm.push(arg)
+				if err != nil {
+					return vm.runtimeError(ip, err)
+				}
+			}
+
+			err := vm.executeCall(len(args) + len(names), names)
+			if err != nil {
+				return vm.runtimeError(ip, err)
+			}
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+
+			if vm.framesIndex == 1 {
+				return vm.exitMain(returnValue)
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err := vm.push(returnValue)
+			if err != nil {
+				return err
+			}
+		case code.OpReturn:
+			if vm.framesIndex == 1 {
+				return vm.exitMain(Null)
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err := vm.push(Null)
+			if err != nil {
+				return err
+			}
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip + 1:])
+			numFree := code.ReadUint8(ins[ip + 3:])
+			vm.currentFrame().ip += 3
+
+			err := vm.pushClosure(int(constIndex), int(numFree))
+			if err != nil {
+				return err
+			}
 		}
 	}
 	
 	return nil
 }
 
+// exitMain handles a return at the top level of the program, which ends it
+// with the returned value as its result.
+func (vm *VM) exitMain(returnValue object.Object) error {
+	vm.sp = 0
+	vm.stack[0] = returnValue
+	return nil
+}
+
+func (vm *VM) runtimeError(ip int, err error) error {
+	message := err.Error()
+	if tok, ok := vm.currentFrame().cl.Fn.Positions[ip]; ok {
+		message = fmt.Sprintf("%s at line %d, column %d", message, tok.Line, tok.Column)
+	}
+
+	for i := vm.framesIndex - 1; i > 0; i-- {
+		caller := vm.frames[i - 1]
+		message = object.Trace(message, vm.frames[i].cl.Fn.Name, callPosition(caller))
+	}
+
+	return errors.New(message)
+}
+
+// undeclaredError reports reading a variable that has no value yet, which
+// only a function declared further down than the code calling it can lack.
+func (vm *VM) undeclaredError(ip int) error {
+	tok := vm.currentFrame().cl.Fn.Positions[ip]
+	return fmt.Errorf("identifier not found: %s", tok.Literal)
+}
+
+// callPosition finds where the call a frame is waiting on was written. The
+// frame's ip is left on the last operand of the call, and the call is the
+// closest instruction at or before it with a recorded position.
+func callPosition(f *Frame) token.Token {
+	var tok token.Token
+	start := -1
+	for offset, t := range f.cl.Fn.Positions {
+		if offset <= f.ip && offset > start {
+			start, tok = offset, t
+		}
+	}
+
+	return tok
+}
+
+// argumentNames reads the names of the named arguments of a call from the
+// constant array the compiler left them in.
+func (vm *VM) argumentNames(constIndex int) []string {
+	names := []string{}
+	for _, name := range vm.constants[constIndex].(*object.Array).Elements {
+		names = append(names, name.(*object.String).Value)
+	}
+
+	return names
+}
+
+// executeCall calls the function below the arguments on the stack. names
+// holds the names of the last arguments, which are passed by name.
+func (vm *VM) executeCall(numArgs int, names []string) error {
+	callee := vm.stack[vm.sp - 1 - numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs, names)
+	case *object.Builtin:
+		if len(names) > 0 {
+			return fmt.Errorf("builtin functions do not take named arguments")
+		}
+		return vm.callBuiltin(callee, numArgs)
+	default:
+		return fmt.Errorf("not a function: %s", callee.Type())
+	}
+}
+
+func (vm *VM) callClosure(cl *object.Closure, numArgs int, names []string) error {
+	frame := NewFrame(cl, vm.sp - numArgs)
+	if frame.basePointer + cl.Fn.NumLocals >= StackSize {
+		return fmt.Errorf("stack overflow")
+	}
+
+	// The parameters take the slots of the arguments, with nil for the
+	// ones the function fills in from their defaults.
+	values, err := cl.Fn.Arity.Bind(vm.stack[frame.basePointer:vm.sp], names)
+	if err != nil {
+		return err
+	}
+	copy(vm.stack[frame.basePointer:], values)
+	vm.sp = frame.basePointer + len(values)
+
+	err = vm.pushFrame(frame)
+	if err != nil {
+		return err
+	}
+
+	// Slots left behind by an earlier call may still hold its cells.
+	for i := vm.sp; i < frame.basePointer + cl.Fn.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+	vm.sp = frame.basePointer + cl.Fn.NumLocals
+
+	return nil
+}
+
+func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) error {
+	args := vm.stack[vm.sp - numArgs:vm.sp]
+
+	result := builtin.Fn(args...)
+	vm.sp = vm.sp - numArgs - 1
+
+	if err, ok := result.(*object.Error); ok {
+		return errors.New(err.Message)
+	}
+
+	if result != nil {
+		return vm.push(result)
+	}
+	return vm.push(Null)
+}
+
+func (vm *VM) pushClosure(constIndex int, numFree int) error {
+	constant := vm.constants[constIndex]
+	function, ok := constant.(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %+v", constant)
+	}
+
+	free := make([]*object.Cell, numFree)
+	for i := 0; i < numFree; i++ {
+		free[i] = vm.stack[vm.sp - numFree + i].(*object.Cell)
+	}
+	vm.sp = vm.sp - numFree
+
+	closure := &object.Closure{Fn: function, Free: free}
+	return vm.push(closure)
+}
+
+func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
+	elements := make([]object.Object, endIndex - startIndex)
+
+	for i := startIndex; i < endIndex; i++ {
+		elements[i - startIndex] = vm.stack[i]
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
+	hashedPairs := make(map[object.HashKey]object.HashPair)
+
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i + 1]
+
+		pair := object.HashPair{Key: key, Value: value}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		}
+
+		hashedPairs[hashKey.HashKey()] = pair
+	}
+
+	return &object.Hash{Pairs: hashedPairs}, nil
+}
+
+func (vm *VM) executeIndexExpression(left, index object.Object) error {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return vm.executeArrayIndex(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return vm.executeStringIndex(left, index)
+	case left.Type() == object.RANGE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return vm.executeRangeIndex(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return vm.executeHashIndex(left, index)
+	case index.Type() == object.RANGE_OBJ:
+		sliced, err := object.Slice(left, index.(*object.Range))
+		if err != nil {
+			return err
+		}
+		return vm.push(sliced)
+	default:
+		return fmt.Errorf("index operator not supported: %s", left.Type())
+	}
+}
+
+func (vm *VM) executeArrayIndex(array, index object.Object) error {
+	arrayObject := array.(*object.Array)
+	i, ok := object.ResolveIndex(index.(*object.Integer).Value, int64(len(arrayObject.Elements)))
+
+	if !ok {
+		return vm.push(Null)
+	}
+
+	return vm.push(arrayObject.Elements[i])
+}
+
+func (vm *VM) executeStringIndex(str, index object.Object) error {
+	runes := []rune(str.(*object.String).Value)
+	i, ok := object.ResolveIndex(index.(*object.Integer).Value, int64(len(runes)))
+
+	if !ok {
+		return vm.push(Null)
+	}
+
+	return vm.push(&object.String{Value: string(runes[i])})
+}
+
+func (vm *VM) executeRangeIndex(r, index object.Object) error {
+	rangeObject := r.(*object.Range)
+	value, ok := rangeObject.Index(index.(*object.Integer).Value)
+	if !ok {
+		return vm.push(Null)
+	}
+
+	return vm.push(&object.Integer{Value: value})
+}
+
+func (vm *VM) executeHashIndex(hash, index object.Object) error {
+	hashObject := hash.(*object.Hash)
+
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return fmt.Errorf("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hashObject.Pairs[key.HashKey()]
+	if !ok {
+		return vm.push(Null)
+	}
+
+	return vm.push(pair.Value)
+}
+
 func (vm *VM) push(o object.Object) error {
 	if vm.sp >= StackSize {
 		return fmt.Errorf("stack overflow")
@@ -115,8 +880,15 @@ func (vm *VM) executeBinaryOperation(op code.Opcode) error {
 	leftType := left.Type()
 	rightType := right.Type()
 			
-	if leftType == object.INTEGER_OBJ && rightType == object.INTEGER_OBJ {
+	switch {
+	case leftType == object.INTEGER_OBJ && rightType == object.INTEGER_OBJ:
 		return vm.executeBinaryIntegerOperation(op, left, right)
+	case object.IsInteger(left) && object.IsInteger(right):
+		return vm.executeBinaryBigIntOperation(op, left, right)
+	case object.IsNumber(left) && object.IsNumber(right):
+		return vm.executeBinaryFloatOperation(op, left, right)
+	case leftType == object.STRING_OBJ && rightType == object.STRING_OBJ:
+		return vm.executeBinaryStringOperation(op, left, right)
 	}
 
 	return fmt.Errorf("unsupported types for binary operation: %s %s", leftType, rightType)
@@ -130,6 +902,18 @@ func (vm *VM) executeComparison(op code.Opcode) error {
 		return vm.executeIntegerComparison(op, left, right)
 	}
 
+	if object.IsInteger(left) && object.IsInteger(right) {
+		return vm.executeBigIntComparison(op, left, right)
+	}
+
+	if object.IsNumber(left) && object.IsNumber(right) {
+		return vm.executeFloatComparison(op, left, right)
+	}
+
+	if left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ {
+		return vm.executeStringComparison(op, left, right)
+	}
+
 	switch op {
 	case code.OpEqual:
 		return vm.push(nativeBoolToBooleanObject(right == left))
@@ -143,24 +927,63 @@ func (vm *VM) executeComparison(op code.Opcode) error {
 func (vm *VM) executeBinaryIntegerOperation(op code.Opcode, left, right object.Object) error {
 	leftValue := left.(*object.Integer).Value
 	rightValue := right.(*object.Integer).Value
-	
-	var result int64
-	switch op {
-	case code.OpAdd:
-		result = leftValue + rightValue
-	case code.OpSub:
-		result = leftValue - rightValue
-	case code.OpMul:
-		result = leftValue * rightValue
-	case code.OpDiv:
-		result = leftValue / rightValue
-	case code.OpExp:
-		result = int64(math.Pow(float64(leftValue), float64(rightValue)))
-	default:
+
+	operator, ok := arithmeticOperators[op]
+	if !ok {
 		return fmt.Errorf("unknown integer operater %#x", op)
 	}
+
+	result, err := object.IntegerArithmetic(operator, leftValue, rightValue)
+	if err != nil {
+		return err
+	}
 	
-	return vm.push(&object.Integer{Value: result})
+	return vm.push(result)
+}
+
+func (vm *VM) executeBinaryBigIntOperation(op code.Opcode, left, right object.Object) error {
+	leftValue, _ := object.ToBigInt(left)
+	rightValue, _ := object.ToBigInt(right)
+
+	operator, ok := arithmeticOperators[op]
+	if !ok {
+		return fmt.Errorf("unknown integer operater %#x", op)
+	}
+
+	result, err := object.BigIntArithmetic(operator, leftValue, rightValue)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(result)
+}
+
+func (vm *VM) executeBinaryFloatOperation(op code.Opcode, left, right object.Object) error {
+	leftValue, _ := object.ToFloat(left)
+	rightValue, _ := object.ToFloat(right)
+
+	operator, ok := arithmeticOperators[op]
+	if !ok {
+		return fmt.Errorf("unknown float operater %#x", op)
+	}
+
+	result, err := object.FloatArithmetic(operator, leftValue, rightValue)
+	if err != nil {
+		return fmt.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	return vm.push(result)
+}
+
+func (vm *VM) executeBinaryStringOperation(op code.Opcode, left, right object.Object) error {
+	if op != code.OpAdd {
+		return fmt.Errorf("unknown string operator: %d", op)
+	}
+
+	leftValue := left.(*object.String).Value
+	rightValue := right.(*object.String).Value
+
+	return vm.push(&object.String{Value: leftValue + rightValue})
 }
 
 func (vm *VM) executeIntegerComparison(op code.Opcode, left, right object.Object) error {
@@ -181,6 +1004,55 @@ func (vm *VM) executeIntegerComparison(op code.Opcode, left, right object.Object
 	}
 }
 
+func (vm *VM) executeBigIntComparison(op code.Opcode, left, right object.Object) error {
+	result := object.CompareIntegers(left, right)
+
+	switch op {
+	case code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(result == 0))
+	case code.OpNotEqual:
+		return vm.push(nativeBoolToBooleanObject(result != 0))
+	case code.OpGreaterThan:
+		return vm.push(nativeBoolToBooleanObject(result > 0))
+	case code.OpGreaterThanEqual:
+		return vm.push(nativeBoolToBooleanObject(result >= 0))
+	default:
+		return fmt.Errorf("unknown comparison operater %#x", op)
+	}
+}
+
+func (vm *VM) executeFloatComparison(op code.Opcode, left, right object.Object) error {
+	leftValue, _ := object.ToFloat(left)
+	rightValue, _ := object.ToFloat(right)
+
+	switch op {
+	case code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue == rightValue))
+	case code.OpNotEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue != rightValue))
+	case code.OpGreaterThan:
+		return vm.push(nativeBoolToBooleanObject(leftValue > rightValue))
+	case code.OpGreaterThanEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue >= rightValue))
+	default:
+		return fmt.Errorf("unknown comparison operater %#x", op)
+	}
+}
+
+func (vm *VM) executeStringComparison(op code.Opcode, left, right object.Object) error {
+	leftValue := left.(*object.String).Value
+	rightValue := right.(*object.String).Value
+
+	switch op {
+	case code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue == rightValue))
+	case code.OpNotEqual:
+		return vm.push(nativeBoolToBooleanObject(leftValue != rightValue))
+	default:
+		return fmt.Errorf("unknown operator: %d (%s %s)", op, left.Type(), right.Type())
+	}
+}
+
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
 		return True
@@ -191,12 +1063,47 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 
 func (vm *VM) executeMinusOperator() error {
 	operand := vm.pop()
-			if operand.Type() != object.INTEGER_OBJ {
-				return fmt.Errorf("unsupported type for negation: %s", operand.Type())
-			}
 
-			value := operand.(*object.Integer).Value
-			return vm.push(&object.Integer{Value: -value})
+	switch operand := operand.(type) {
+	case *object.Integer, *object.BigInt:
+		return vm.push(object.NegateInteger(operand))
+	case *object.Float:
+		return vm.push(&object.Float{Value: -operand.Value})
+	default:
+		return fmt.Errorf("unsupported type for negation: %s", operand.Type())
+	}
+}
+
+func (vm *VM) executeInterpolation(numParts int) error {
+	var out strings.Builder
+
+	for _, part := range vm.stack[vm.sp - numParts:vm.sp] {
+		out.WriteString(part.Inspect())
+	}
+	vm.sp = vm.sp - numParts
+
+	return vm.push(&object.String{Value: out.String()})
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
+		return false
+	default:
+		return true
+	}
+}
+
+func (vm *VM) executeBitNotOperator() error {
+	operand := vm.pop()
+
+	if !object.IsInteger(operand) {
+		return fmt.Errorf("unsupported type for bitwise not: %s", operand.Type())
+	}
+
+	return vm.push(object.InvertInteger(operand))
 }
 
 func (vm *VM) executeBangOperator() error {
@@ -207,6 +1114,8 @@ func (vm *VM) executeBangOperator() error {
 		return vm.push(False)
 	case False:
 		return vm.push(True)
+	case Null:
+		return vm.push(True)
 	default:
 		return vm.push(False)
 	}
@@ -7,6 +7,8 @@ import (
 	"goblin/lexer"
 	"goblin/object"
 	"goblin/parser"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -24,10 +26,64 @@ func TestIntegerArithmetic(t *testing.T) {
 		{"2 * 2", 4},
 		{"30 / 3", 10},
 		{"2 ** 8", 256},
+		{"2 ** 3 ** 2", 512},
 		{"-5", -5},
 		{"-10", -10},
 		{"-50 + 100 + -50", 0},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"3 ** 39", 4052555153018976267},
+		{"2 ** -2", 0.25},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7.5 % 2", 1.5},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"~5", -6},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"1 << 63 >> 63", 1},
+		{"(2 ** 64 | 1) & 3", 1},
+		{"~(2 ** 64) + 2 ** 64", -1},
+		{"0 ** (2 ** 64)", 0},
+		{"(-1) ** (2 ** 64 + 1)", -1},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"2.5", 2.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"7 / 2.0", 3.5},
+		{"10.0 - 2", 8.0},
+		{"2 ** -1.0", 0.5},
+		{"1.5 > 1", true},
+		{"1 < 1.5", true},
+		{"1 == 1.0", true},
+		{"2 <= 1.5", false},
+		{"1.0 != 1", false},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBigIntArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"9223372036854775807 + 1", bigInt("9223372036854775808")},
+		{"-9223372036854775807 - 2", bigInt("-9223372036854775809")},
+		{"3037000500 * 3037000500", bigInt("9223372037000250000")},
+		{"2 ** 64", bigInt("18446744073709551616")},
+		{"-(-9223372036854775807 - 1)", bigInt("9223372036854775808")},
+		{"123456789012345678901234567890 * 10 / 10", bigInt("123456789012345678901234567890")},
+		{"2 ** 64 - 2 ** 64 + 5", 5},
+		{"2 ** 64 > 9223372036854775807", true},
+		{"1 < 2 ** 64", true},
+		{"2 ** 64 == 18446744073709551616", true},
+		{"2 ** 64 >= 1.5", true},
 	}
 
 	runVmTests(t, tests)
@@ -63,6 +119,542 @@ func TestBooleanExpressions(t *testing.T) {
 
 	runVmTests(t, tests)
 }
+func TestLogicalExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 2", 2},
+		{"false && 2", false},
+		{"1 || 2", 1},
+		{"false || 2", 2},
+		{"1 < 2 && 2 < 3", true},
+		{"false && 1 / 0", false},
+		{"true || 1 / 0", true},
+		{"false || true && false", false},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (true) { 10 }", 10},
+		{"if (true) { 10 } else { 20 }", 10},
+		{"if (false) { 10 } else { 20 } ", 20},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", Null},
+		{"if (false) { 10 }", Null},
+		{"if ((if (false) { 10 })) { 10 } else { 20 }", 20},
+		{"!(if (false) { 5; })", true},
+		{"if (true) { let a = 1; }", Null},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 } else { 30 }", 30},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 }", Null},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSwitchExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"switch (2) { case 1: 10 case 2: 20 default: 30 }", 20},
+		{"switch (5) { case 1: 10 case 2: 20 default: 30 }", 30},
+		{"switch (5) { case 1: 10 }", Null},
+		{"switch (3) { case 1, 2: 10 case 3, 4: 20 }", 20},
+		{"switch (1) { case 1, 2: 10 case 3, 4: 20 }", 10},
+		{`switch ("b") { case "a": 1 case "b": 2 }`, 2},
+		{"switch (2.0) { case 1: 1 case 2: 2 }", 2},
+		{"switch (1) { case 1: }", Null},
+		{"let x = 0; switch (1) { case 1: x = 5; x += 1; case 2: x = 100 }; x", 6},
+		{"let f = fn(n) { switch (n) { case 0: return 100; default: n } }; f(0) + f(1)", 101},
+		{"let s = 0; for (i in 0..5) { switch (i) { case 2: continue; case 4: break; } s += i }; s", 4},
+		{"let f = fn(x) { switch (x) { case 1: \"one\" case 2: \"two\" default: \"many\" } }; f(1) + f(2) + f(3)", "onetwomany"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestGlobalLetStatements(t *testing.T) {
+	tests := []vmTestCase{
+		{"let one = 1; one", 1},
+		{"let one = 1; let two = 2; one + two", 3},
+		{"let one = 1; let two = one + one; one + two", 3},
+		{"let one = 1; let one = one + 1; one", 2},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCollections(t *testing.T) {
+	tests := []vmTestCase{
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][3]", Null},
+		{"[[1, 1 + 1]][0][1]", 2},
+		{`{1: 1, 2: 2}[2]`, 2},
+		{`{1: 1, 2: 2}[2.0]`, 2},
+		{`{1.0: 1, 2.5: 2}[1]`, 1},
+		{`{"a": 1}["b"]`, Null},
+		{`len([1, 2, 3])`, 3},
+		{`last(push([1], 2))`, 2},
+		{`first([])`, Null},
+		{"let a = [1, 2, 3]; a[1] = 5; a[1]", 5},
+		{"let a = [1, 2, 3]; a[0] = a[2] = 7; a[0] + a[2]", 14},
+		{"let a = [1, 2, 3]; let b = a; b[2] = 10; a[2]", 10},
+		{"let a = [0]; let f = fn(arr) { arr[0] = 9 }; f(a); a[0]", 9},
+		{`let h = {"a": 1}; h["a"] = 2; h["b"] = 3; h["a"] + h["b"]`, 5},
+		{"let a = [1]; a[0] = 4", 4},
+		{"let a = [1, 2, 3]; a[1] += 5; a[1]", 7},
+		{`let h = {"n": 10}; h["n"] -= 4; h["n"] *= 3; h["n"] /= 2`, 9},
+		{"let m = [[1]]; m[0][0] += 1; m[0][0]", 2},
+		{"let a = [1, 2]; let i = 0; let next = fn() { i += 1; i - 1 }; a[next()] += 10; a[0] * 10 + i", 111},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCallingFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let f = fn() { 5 + 10; }; f();", 15},
+		{"let f = fn() { return 99; 100; }; f();", 99},
+		{"let f = fn() { }; f();", Null},
+		{"let sum = fn(a, b) { let c = a + b; c; }; sum(1, 2) + sum(3, 4);", 10},
+		{"let f = fn() { 1 }; let g = fn() { f() + 1 }; g() + g()", 4},
+		{"let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(15)", 610},
+		{"let f = fn() { let g = fn(n) { if (n == 0) { 0 } else { g(n - 1) } }; g(3) }; f()", 0},
+		{"return 5; 10", 5},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{"let newAdder = fn(a) { fn(b) { a + b } }; newAdder(2)(3)", 5},
+		{"let f = fn(a) { fn(b) { fn(c) { a + b + c } } }; f(1)(2)(3)", 6},
+		{`
+		let counter = fn() {
+			let count = 0;
+			fn() { count += 1 }
+		};
+		let a = counter();
+		let b = counter();
+		a(); a(); b();
+		a()`, 3},
+		{`
+		let pair = fn() {
+			let n = 0;
+			let inc = fn() { n += 1 };
+			inc();
+			inc();
+			n
+		};
+		pair()`, 2},
+		{`
+		let f = fn(x) {
+			let get = fn() { x };
+			let set = fn() { fn() { x = 10 } };
+			set()();
+			get()
+		};
+		f(1)`, 10},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []vmTestCase{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = 2", 2},
+		{"let x = 1; let y = 1; x = y = 5; x + y", 10},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let x = 1; let f = fn() { x += 1 }; f(); f(); x", 3},
+		{"let f = fn(a) { a *= 3; a }; f(4)", 12},
+		{"let x = 0; let i = 0; while (i < 5) { i += 1; x += i }; x", 15},
+		{"let i = 0; while (i < 3) { i += 1 }", 3},
+		{"while (false) { 1 }", Null},
+		{"const a = 5; a", 5},
+		{"const a = [1]; a[0] = 2; a[0]", 2},
+		{"let a = 1; const a = 2; a", 2},
+		{"const a = 1; let f = fn() { let a = 2; a = 3 }; f() + a", 4},
+		{"const f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(3)", 0},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{"let s = 0; for (x in [1, 2, 3]) { s += x }; s", 6},
+		{"let s = 0; for (i, x in [10, 20]) { s += i * x }; s", 20},
+		{`let s = ""; for (c in "héllo") { s = c + s }; s`, "olléh"},
+		{`let s = ""; for (k in {"b": 1, "a": 2}) { s += k }; s`, "ab"},
+		{`let s = 0; for (k, v in {"b": 1, "a": 2}) { s = s * 10 + v }; s`, 21},
+		{"for (x in [1]) { x }", Null},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } s += x }; s", 3},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x % 2 == 0) { continue; } s += x }; s", 4},
+		{"let s = 0; for (x in [1, 2]) { for (y in [1, 2, 3]) { if (y == 2) { break; } s += x * y } }; s", 3},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } }; i", 5},
+		{"let i = 0; let s = 0; while (i < 5) { i += 1; if (i == 2) { continue; } s += i }; s", 13},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } }", Null},
+		{"let f = fn(a) { for (x in a) { if (x > 1) { return x } }; 0 }; f([1, 5, 7])", 5},
+		{"let fs = []; for (x in [1, 2]) { let f = fn() { x }; if (x == 1) { fs = [f] } }; fs[0]()", 1},
+		{"let x = 100; for (x in [1, 2]) {}; x", 100},
+		{"let fs = []; for (i in [1, 2, 3]) { fs = push(fs, fn() { i }) }; fs[0]() * 10 + fs[1]()", 12},
+		{"let fs = []; for (i in [1, 2, 3]) { let j = i * 2; fs = push(fs, fn() { j }) }; fs[0]() * 10 + fs[2]()", 26},
+		{"let fs = []; for (i in [1, 2]) { fs = push(fs, fn() { i += 10; i }) }; fs[0](); fs[0]() * 100 + fs[1]()", 2112},
+		{"let f = fn() { let fs = []; for (i in [1, 2, 3]) { fs = push(fs, fn() { i }) }; fs[0]() * 10 + fs[1]() }; f()", 12},
+		{"let f = fn() { let x = 100; for (x in [1, 2]) {}; x }; f()", 100},
+		{"let s = 0; for (x in [1, 2]) { let y = x; s += y }; s", 3},
+		{"const x = 1; let s = 0; for (x in [5]) { s = x }; s + x", 6},
+		{"let s = 0; for (x in [1, 2, 3]) { s += [x, if (x == 2) { continue } else { 0 }][0] }; s", 4},
+		{"let s = []; for (x in [1, 2, 3]) { s = push(s, [x, switch (x) { case 2: continue default: x }]) }; len(s) * 10 + s[1][1]", 23},
+		{"let s = 0; for (x in [1, 2, 3]) { s += 1 + if (x == 2) { break } else { x } }; s", 2},
+		{"let s = 0; for (x in 1..=4) { s += len([x, if (x > 2) { break } else { x }]) }; s", 4},
+		{"let i = 0; let s = 0; while (i < 5) { i += 1; s += {i: if (i == 3) { continue } else { i }}[i] }; s", 12},
+		{"let s = 0; for (x in [1, 2]) { for (y in [1, 2]) { s += x * if (y == 2) { break } else { y } } }; s", 3},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRanges(t *testing.T) {
+	tests := []vmTestCase{
+		{"len(0..10)", 10},
+		{"len(0..=10)", 11},
+		{"len(0..10 step 3)", 4},
+		{"len(10..0 step -2)", 5},
+		{"len(5..1)", 0},
+		{"(0..10 step 3)[2]", 6},
+		{"(0..3)[3]", Null},
+		{"let s = 0; for (i in 1..=4) { s += i }; s", 10},
+		{"let s = 0; for (i, x in 10..0 step -5) { s += i * x }; s", 5},
+		{"4 in 0..10 step 2", true},
+		{"5 in 0..10 step 2", false},
+		{"10 in 0..=10", true},
+		{"(0..=9223372036854775807)[-1]", 9223372036854775807},
+		{"let s = 0; for (i in 0..=9223372036854775807) { if (i == 3) { break } s += i }; s", 3},
+		{"9223372036854775806 in 0..=9223372036854775807", true},
+		{`2 in [1, 2, 3] && "b" in {"b": 1} && "ell" in "hello"`, true},
+		{"4 in [1, 2, 3]", false},
+		{"let a = [1, 2, 3, 4]; let b = a[1..3]; len(b) * 10 + b[0]", 22},
+		{`"hello"[1..4]`, "ell"},
+		{"let n = 3; 1..=n step 2", &object.Range{Start: 1, End: 3, Step: 2, Inclusive: true}},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{`"héllo"[1]`, "é"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[:-2]`, "hel"},
+		{`"hello"[3:]`, "lo"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"hello"[::2]`, "hlo"},
+		{`"hello"[-100:100]`, "hello"},
+		{`"hello"[4:1]`, ""},
+		{"let a = [1, 2, 3, 4, 5]; let b = a[1:4]; len(b) * 10 + b[0]", 32},
+		{"let a = [1, 2, 3, 4, 5]; a[-2:][0]", 4},
+		{"let a = [1, 2, 3, 4, 5]; a[:][4]", 5},
+		{"let a = [1, 2, 3, 4, 5]; let b = a[4:0:-2]; len(b) * 10 + b[1]", 23},
+		{"let a = [1, 2, 3]; let b = a[:]; b[0] = 9; a[0]", 1},
+		{"len([1, 2, 3][5:])", 0},
+		{"(0..10)[-1]", 9},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-4]", Null},
+		{"let a = [1, 2]; a[-1] = 5; a[1]", 5},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"match (1) { 1 => 10, _ => 20 }", 10},
+		{"match (2) { 1 => 10, _ => 20 }", 20},
+		{"match (3) { 1 => 10 }", Null},
+		{"match (-1) { -1 => 10, _ => 20 }", 10},
+		{`match ("b") { "a" => 1, "b" => 2, _ => 3 }`, 2},
+		{"match (true) { false => 1, true => 2 }", 2},
+		{"match (5) { n => n * 2 }", 10},
+		{"match (5) { n if n > 10 => 1, n if n > 0 => 2, _ => 3 }", 2},
+		{"match (-5) { n if n > 10 => 1, n if n > 0 => 2, _ => 3 }", 3},
+		{"match ([1, 2, 3]) { [] => 0, [a] => a, [a, b, c] => a + b + c }", 6},
+		{"match ([1, 2]) { [a, b, c] => 1, [a, b] => 2 }", 2},
+		{"match ([1, 2, 3]) { [first, ...rest] => first * 10 + len(rest) }", 12},
+		{"match ([1]) { [first, ...rest] => len(rest) }", 0},
+		{"match ([]) { [first, ...rest] => 1, _ => 2 }", 2},
+		{"match ([1, 2, 3]) { [..._] => 7 }", 7},
+		{"match ([1, 2, 3]) { [first, ...] => first }", 1},
+		{"match ([1]) { [_, _, ...] => 0, [...] => 7 }", 7},
+		{"let [a, ...] = [4, 5, 6]; a", 4},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b * c }", 7},
+		{"match ([1, [2]]) { [a, [b, c]] => 1, [a, [b]] => 2 }", 2},
+		{"match ([1, 2]) { [2, x] => x, [1, x] => x * 10 }", 20},
+		{`match ({"type": "add", "x": 3, "y": 4}) { {"type": "sub", "x": x, "y": y} => x - y, {"type": "add", "x": x, "y": y} => x + y }`, 7},
+		{`match ({"x": 1}) { {"x": 1, "y": y} => y, {"x": x} => x + 100 }`, 101},
+		{`match ({"p": [1, 2]}) { {"p": [a, b]} => a + b }`, 3},
+		{`match ({"p": [1, 2]}) { {"p": [a]} => a, {"p": [a, b, ...r]} => len(r) }`, 0},
+		{"match (1) { [a] => a, {} => 2, _ => 3 }", 3},
+		{`match ({"x": 1, "y": 2}) { {x: 2} => 0, {x, y} => x + y }`, 3},
+		{"match (1) { x => { let y = x + 1; y * 2 } }", 4},
+		{"match ([1, 2]) { [a, b] if a > b => 1, [a, b] => a - b }", -1},
+		{"let f = fn(xs) { match (xs) { [] => 0, [x, ...rest] => x + f(rest) } }; f([1, 2, 3, 4])", 10},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { match (x) { 3 => break, n => s += n } }; s", 3},
+		{"let g = fn(v) { match (v) { [a, [b, 9]] => a + b, _ => 0 } }; g([1, [2, 9]]) + g([1, [2, 3]]) + g([1, 2])", 3},
+		{"let f = fn(v) { match (v) { [a, b] => a + b, _ => 0 } }; f([1, 2]) + f(5)", 3},
+		{"let x = 10; match (5) { x if x > 100 => 0, _ => 1 }; x", 10},
+		{"let x = 10; let y = match (5) { x => x * 2 }; y + x", 20},
+		{"let f = fn() { let x = 10; match (5) { x if x > 100 => 0, _ => 1 }; x }; f()", 10},
+		{"let x = 10; match ([1, 2]) { [x, 3] => 0, _ => x }", 10},
+		{"const x = 1; match (2) { x => x }", 2},
+		{"const x = 1; match (2) { x => x }; x", 1},
+		{"let g = match (3) { n => fn() { n } }; let n = 7; g()", 3},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestStringExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{`"a" == "a"`, true},
+		{`"a" != "b"`, true},
+		{`"goblin"`, "goblin"},
+		{`"gob" + "lin"`, "goblin"},
+		{`"gob" + "lin" + "s"`, "goblins"},
+		{`"one ${1 + 1} three"`, "one 2 three"},
+		{`"${true} and ${"nested ${5 * 5}"}"`, "true and nested 25"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []vmTestCase{
+		{"let f = fn(x, y = 10) { x + y }; f(1)", 11},
+		{"let f = fn(x, y = 10) { x + y }; f(1, 2)", 3},
+		{"let f = fn(x, y = x * 2) { x + y }; f(3)", 9},
+		{"let f = fn(first, ...others) { first + len(others) }; f(1, 2, 3)", 3},
+		{"let f = fn(...xs) { len(xs) }; f()", 0},
+		{"let f = fn(a, b = 2, ...r) { a * 100 + b * 10 + len(r) }; f(1)", 120},
+		{"let f = fn(a, b = 2, ...r) { a * 100 + b * 10 + len(r) }; f(1, 3, 4, 5)", 132},
+		{"let f = fn(...xs) { xs[1] }; f(1, 2, 3)", 2},
+		{"let f = fn(x, y) { x - y }; f(y: 1, x: 5)", 4},
+		{"let f = fn(x, y = 1, z = 2) { x + y * 10 + z * 100 }; f(0, z: 5)", 510},
+		{"let y = 100; let f = fn(x = y, y = 1) { x + y }; f()", 101},
+		{"let f = fn(x, g = fn() { x * 2 }) { g() }; f(4)", 8},
+		{"let f = fn(n, acc = 1) { if (n == 0) { acc } else { f(n - 1, acc: acc * n) } }; f(5)", 120},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLambdas(t *testing.T) {
+	tests := []vmTestCase{
+		{"let double = |x| x * 2; double(21)", 42},
+		{"let f = || 42; f()", 42},
+		{"let add = |a, b = 10| a + b; add(1)", 11},
+		{"let count = |...xs| len(xs); count(1, 2, 3)", 3},
+		{"let f = |x| { let y = x * 2; y + 1 }; f(2)", 5},
+		{"let apply = fn(f, x) { f(x) }; apply(|n| n + 1, 1)", 2},
+		{"let k = 3; let f = |x| x * k; f(2)", 6},
+		{"let adder = |x| |y| x + y; adder(1)(2)", 3},
+		{"let s = 0; for (x in [1, 2, 3]) { let f = |n| n * 10; s += f(x) }; s", 60},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"[1, 2, 3] |> len()", 3},
+		{"let double = |x| x * 2; 5 |> double", 10},
+		{"let add = fn(a, b) { a + b }; 1 |> add(2) |> add(3)", 6},
+		{"let map = fn(xs, f) { let out = []; for (x in xs) { out = push(out, f(x)) }; out }; [1, 2, 3] |> map(|x| x * 10) |> rest() |> first()", 20},
+		{"let x = 0; x = 3 |> |n| n * n; x", 9},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(b: 4)", 6},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestSpreadExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let a = [1, 2]; let b = [4]; [...a, 3, ...b]", []int{1, 2, 3, 4}},
+		{"[...[], ...[]]", []int{}},
+		{"[0, ...1..=3]", []int{0, 1, 2, 3}},
+		{"let a = [1]; let b = [...a]; b[0] = 5; a", []int{1}},
+		{"let d = {\"x\": 1, \"y\": 2}; let h = {...d, \"y\": 3}; [h[\"x\"], h[\"y\"]]", []int{1, 3}},
+		{"let h = {\"y\": 3, ...{\"y\": 1}}; h[\"y\"]", 3},
+		{"let h = {...{\"a\": 1}, ...{\"a\": 2, \"b\": 3}}; [h[\"a\"], h[\"b\"]]", []int{2, 3}},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(...[1, 2, 3])", 123},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(1, ...[2], 3)", 123},
+		{"let f = fn(a, b, c) { a * 100 + b * 10 + c }; f(...[1, 2], c: 3)", 123},
+		{"let f = fn(a, b = 5, ...r) { [a, b, len(r)] }; f(...[1], ...[2, 3, 4])", []int{1, 2, 2}},
+		{"len(...[\"abc\"])", 3},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLetDestructuring(t *testing.T) {
+	tests := []vmTestCase{
+		{"let [a, b] = [1, 2]; a * 10 + b", 12},
+		{"let [a, b, c] = [1, 2]; c", Null},
+		{"let [a, ...rest] = [1, 2, 3]; a + len(rest)", 3},
+		{"let [a, b, ...rest] = [1]; len(rest)", 0},
+		{"let [_, b] = [1, 2]; b", 2},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b * c", 7},
+		{"let {name, age: years} = {\"name\": 1, \"age\": 2}; name * 10 + years", 12},
+		{"let {\"x\": x, missing} = {\"x\": 4}; x", 4},
+		{"let {missing} = {}; missing", Null},
+		{"let {p: [a, b]} = {\"p\": [5, 6]}; a + b", 11},
+		{"let f = fn(pair) { let [x, y] = pair; x - y }; f([5, 2])", 3},
+		{"const [a, b] = [1, 2]; a + b", 3},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestNamedFunctions(t *testing.T) {
+	runVmTests(t, []vmTestCase{
+		{"fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)", 120},
+		{"fn outer() { fn inner(x) { x + 1 }; inner(1) }; outer()", 2},
+		{"fn outer() { fn a() { b() }; fn b() { 2 }; a() }; outer()", 2},
+		{"fn even(n) { if (n == 0) { true } else { odd(n - 1) } }; fn odd(n) { if (n == 0) { false } else { even(n - 1) } }; even(10)", true},
+	})
+
+	inspects := []struct {
+		input string
+		expected string
+	}{
+		{"fn add(a, b = 1) { a + b }; add", "fn add(a, b = 1)"},
+		{"let twice = |x| x * 2; twice", "fn twice(x)"},
+		{"let f = fn() { 1 }; let g = f; g", "fn f()"},
+		{"|x, y| x", "fn(x, y)"},
+	}
+
+	for _, tt := range inspects {
+		comp := compiler.New()
+		err := comp.Compile(parse(tt.input))
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		if err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+
+		if vm.LastPoppedStackElem().Inspect() != tt.expected {
+			t.Errorf("Inspect() is not %q. got=%q", tt.expected, vm.LastPoppedStackElem().Inspect())
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		expectedError string
+	}{
+		{"1 + true", "unsupported types for binary operation: INTEGER BOOLEAN at line 1, column 3"},
+		{"1 / 0", "division by zero: 1 / 0 at line 1, column 3"},
+		{"let a = [1]; a[0] /= 0", "division by zero: 1 / 0 at line 1, column 19"},
+		{"2 ** 100000000", "exponent too large: 2 ** 100000000 at line 1, column 3"},
+		{"len(0..=9223372036854775807)", "range 0..=9223372036854775807 has more than 9223372036854775807 values at line 1, column 4"},
+		{"10 +\n  (5 - 5 / (3 - 3))", "division by zero: 5 / 0 at line 2, column 10"},
+		{"(2 ** 64) / 0", "division by zero: 18446744073709551616 / 0 at line 1, column 11"},
+		{"-true", "unsupported type for negation: BOOLEAN at line 1, column 1"},
+		{"5 % 0", "division by zero: 5 % 0 at line 1, column 3"},
+		{"1 << -1", "negative shift amount: 1 << -1 at line 1, column 3"},
+		{"1.5 & 1", "unknown operator: FLOAT & INTEGER at line 1, column 5"},
+		{"~true", "unsupported type for bitwise not: BOOLEAN at line 1, column 1"},
+		{"let f = fn() {\n  1 / 0\n}; f()", "division by zero: 1 / 0 at line 2, column 5\n  in f, called at line 3, column 5"},
+		{"fn(a) { a }()", "wrong number of arguments: want=1, got=0 at line 1, column 12"},
+		{"5(1)", "not a function: INTEGER at line 1, column 2"},
+		{`len(1)`, "argument to `len` not supported. got INTEGER at line 1, column 4"},
+		{"{[]: 1}", "unusable as hash key: ARRAY at line 1, column 1"},
+		{"1[0]", "index operator not supported: INTEGER at line 1, column 2"},
+		{"let f = fn() { f() }; f()", "stack overflow at line 1, column 17" + strings.Repeat("\n  in f, called at line 1, column 17", object.MaxTraceCalls) + "\n  ..."},
+		{"let a = [1, 2]; a[2] = 1", "index 2 out of range for array of length 2 at line 1, column 22"},
+		{`let a = [1]; a["0"] = 1`, "array index must be INTEGER, got STRING at line 1, column 21"},
+		{"let h = {}; h[[]] = 1", "unusable as hash key: ARRAY at line 1, column 19"},
+		{"let s = 1; s[0] = 1", "index assignment not supported: INTEGER at line 1, column 17"},
+		{"for (x in 5) { x }", "INTEGER is not iterable at line 1, column 1"},
+		{"1..true", "range bounds must be INTEGER, got BOOLEAN at line 1, column 2"},
+		{"0..10 step 0", "range step cannot be zero at line 1, column 2"},
+		{"[1, 2][1..3]", "index 2 out of range for array of length 2 at line 1, column 7"},
+		{"1 in 5", "in operator not supported: INTEGER at line 1, column 3"},
+		{"[1, 2][::0]", "slice step cannot be zero at line 1, column 7"},
+		{`[1, 2]["a":]`, "slice bounds must be INTEGER, got STRING at line 1, column 7"},
+		{"let a = [1, 2]; a[-3] = 1", "index -3 out of range for array of length 2 at line 1, column 23"},
+		{"let f = fn() { for (x in [1, 0]) { 1 / x } }; f()", "division by zero: 1 / 0 at line 1, column 38\n  in f, called at line 1, column 48"},
+		{"fn(a) { a }(1, 2)", "wrong number of arguments: want=1, got=2 at line 1, column 12"},
+		{"fn(a, b = 1) { a }()", "wrong number of arguments: want=1 to 2, got=0 at line 1, column 19"},
+		{"fn(a, ...r) { a }()", "wrong number of arguments: want=at least 1, got=0 at line 1, column 18"},
+		{"fn(a) { a }(b: 1)", "unknown parameter b at line 1, column 12"},
+		{"fn(a) { a }(1, a: 2)", "parameter a given more than once at line 1, column 12"},
+		{"fn(a, b = 2) { a }(b: 1)", "missing argument for parameter a at line 1, column 19"},
+		{"len(s: \"\")", "builtin functions do not take named arguments at line 1, column 4"},
+		{"fn(a = 1 / 0) { a }()", "division by zero: 1 / 0 at line 1, column 10\n  in anonymous function, called at line 1, column 20"},
+		{"[...1]", "spread operator not supported: INTEGER at line 1, column 2"},
+		{"{...[1]}", "spread operator not supported in hash: ARRAY at line 1, column 2"},
+		{"fn(a) { a }(...5)", "spread operator not supported: INTEGER at line 1, column 13"},
+		{"fn(a) { a }(...[1, 2])", "wrong number of arguments: want=1, got=2 at line 1, column 12"},
+		{"fn(a) { a }(...[1, 2])", "wrong number of arguments: want=1, got=2 at line 1, column 12"},
+		{"let [a, b] = 1;", "cannot destructure INTEGER as ARRAY at line 1, column 5"},
+		{"let {a} = [1];", "cannot destructure ARRAY as HASH at line 1, column 5"},
+		{"let [a, [b]] = [1, 2];", "cannot destructure INTEGER as ARRAY at line 1, column 9"},
+		{
+			"fn inner() { 1 / 0 }\nfn outer() { inner() }\nouter()",
+			"division by zero: 1 / 0 at line 1, column 16\n  in inner, called at line 2, column 19\n  in outer, called at line 3, column 6",
+		},
+		{
+			"let f = fn(g) { g() }; f(|| 1 / 0)",
+			"division by zero: 1 / 0 at line 1, column 31\n  in anonymous function, called at line 1, column 18\n  in f, called at line 1, column 25",
+		},
+		{
+			"fn a() { b() }; fn b() { 1 / 0 }; a()",
+			"division by zero: 1 / 0 at line 1, column 28\n  in b, called at line 1, column 11\n  in a, called at line 1, column 36",
+		},
+		{"a(); fn a() { 1 }", "identifier not found: a at line 1, column 1"},
+		{"fn f() { g() }; f(); fn g() { 1 }", "identifier not found: g at line 1, column 10\n  in f, called at line 1, column 18"},
+		{"fn f() { g(); fn g() { 1 } }; f()", "identifier not found: g at line 1, column 10\n  in f, called at line 1, column 32"},
+		{"fn f() { let h = || g(); h(); fn g() { 1 } }; f()", "identifier not found: g at line 1, column 21\n  in h, called at line 1, column 27\n  in f, called at line 1, column 48"},
+		{
+			"fn down(n) { if (n == 0) { 1 / 0 } else { down(n - 1) } }; down(20)",
+			"division by zero: 1 / 0 at line 1, column 30" + strings.Repeat("\n  in down, called at line 1, column 47", object.MaxTraceCalls) + "\n  ...",
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(tt.input)
+		comp := compiler.New()
+
+		err := comp.Compile(program)
+		if err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(comp.Bytecode())
+		err = vm.Run()
+		if err == nil {
+			t.Fatalf("expected vm error for %q but got none", tt.input)
+		}
+
+		if err.Error() != tt.expectedError {
+			t.Errorf("wrong vm error. expected=%q, got=%q", tt.expectedError, err)
+		}
+	}
+}
+
 func runVmTests(t *testing.T, tests []vmTestCase) {
 	t.Helper()
 
@@ -95,11 +687,55 @@ func testExpectedObject(t *testing.T, expected interface{}, actual object.Object
 		if err != nil {
 			t.Errorf("testIntegerObject failed: %s", err)
 		}
+	case *big.Int:
+		err := testBigIntObject(expected, actual)
+		if err != nil {
+			t.Errorf("testBigIntObject failed: %s", err)
+		}
+	case float64:
+		err := testFloatObject(expected, actual)
+		if err != nil {
+			t.Errorf("testFloatObject failed: %s", err)
+		}
 	case bool:
 		err := testBooleanObject(bool(expected), actual)
 		if err != nil {
 			t.Errorf("testBooleanObject faled: %s", err)
 		}	
+	case string:
+		err := testStringObject(expected, actual)
+		if err != nil {
+			t.Errorf("testStringObject failed: %s", err)
+		}
+	case []int:
+		array, ok := actual.(*object.Array)
+		if !ok {
+			t.Errorf("object is not Array: %T (%+v)", actual, actual)
+			return
+		}
+
+		if len(array.Elements) != len(expected) {
+			t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), len(array.Elements))
+			return
+		}
+
+		for i, expectedElem := range expected {
+			err := testIntegerObject(int64(expectedElem), array.Elements[i])
+			if err != nil {
+				t.Errorf("testIntegerObject failed: %s", err)
+			}
+		}
+	case *object.Null:
+		if actual != Null {
+			t.Errorf("object is not Null: %T (%+v)", actual, actual)
+		}
+	case *object.Range:
+		result, ok := actual.(*object.Range)
+		if !ok {
+			t.Errorf("object is not Range. got=%T (%+v)", actual, actual)
+		} else if *result != *expected {
+			t.Errorf("object has wrong value. expected=%s, got=%s", expected.Inspect(), result.Inspect())
+		}
 	}
 }
 
@@ -133,4 +769,48 @@ func testBooleanObject(expected bool, actual object.Object) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func testStringObject(expected string, actual object.Object) error {
+	result, ok := actual.(*object.String)
+	if !ok {
+		return fmt.Errorf("object is not String. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("object has wrong value. expected=%q, got=%q", expected, result.Value)
+	}
+
+	return nil
+}
+
+func testFloatObject(expected float64, actual object.Object) error {
+	result, ok := actual.(*object.Float)
+	if !ok {
+		return fmt.Errorf("object is not Float. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value != expected {
+		return fmt.Errorf("object has wrong value. expected=%g, got=%g", expected, result.Value)
+	}
+
+	return nil
+}
+
+func testBigIntObject(expected *big.Int, actual object.Object) error {
+	result, ok := actual.(*object.BigInt)
+	if !ok {
+		return fmt.Errorf("object is not BigInt. got=%T (%+v)", actual, actual)
+	}
+
+	if result.Value.Cmp(expected) != 0 {
+		return fmt.Errorf("object has wrong value. expected=%s, got=%s", expected, result.Value)
+	}
+
+	return nil
+}
+
+func bigInt(value string) *big.Int {
+	result, _ := new(big.Int).SetString(value, 10)
+	return result
+}